@@ -2,8 +2,10 @@ package skytable
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/satvik007/skytable-go/internal"
@@ -20,13 +22,71 @@ type Cmder interface {
 	firstKeyPos() int8
 	SetFirstKeyPos(int8)
 
+	// Idempotent reports whether the command can be safely sent again
+	// after the server may already have applied it.
+	Idempotent() bool
+	SetIdempotent(bool)
+
 	readTimeout() *time.Duration
 	readReply(rd *proto.Reader) error
 
+	// BytesWritten and BytesRead return the number of bytes of the command
+	// and of its reply, summed over all attempts. Hooks can read them in
+	// AfterProcess and AfterProcessPipeline.
+	BytesWritten() int64
+	BytesRead() int64
+	addBytes(written, read int64)
+
+	// Attempts returns the number of times the command was sent, i.e. 1
+	// plus the number of retries. It is 0 if it was rejected before being
+	// sent, e.g. by Options.ReadOnly.
+	Attempts() int
+	addAttempt()
+
+	encodeKeys()
+
+	// SetMeta and Meta attach application data to the command, e.g. for
+	// hooks to correlate it with a request. It is never sent to the server.
+	SetMeta(key string, val interface{})
+	Meta(key string) interface{}
+
+	// Attrs returns the attributes the server sent along with the reply,
+	// or nil if there were none.
+	Attrs() map[string]interface{}
+	setAttrs(map[string]interface{})
+
 	SetErr(error)
 	Err() error
 }
 
+// Scanner is implemented by types that decode themselves from a reply value,
+// like sql.Scanner. src is the value as returned by the reader: a string,
+// a []byte, an int64 or a float64.
+type Scanner interface {
+	ScanSkytable(src interface{}) error
+}
+
+// scan stores the reply value src in dst, calling dst.ScanSkytable
+// if dst implements Scanner.
+func scan(src, dst interface{}) error {
+	if s, ok := dst.(Scanner); ok {
+		return s.ScanSkytable(src)
+	}
+
+	switch src := src.(type) {
+	case string:
+		return proto.Scan([]byte(src), dst)
+	case []byte:
+		return proto.Scan(src, dst)
+	case int64:
+		return proto.Scan(strconv.AppendInt(nil, src, 10), dst)
+	case float64:
+		return proto.Scan(strconv.AppendFloat(nil, src, 'f', -1, 64), dst)
+	default:
+		return fmt.Errorf("skytable: can't scan %T", src)
+	}
+}
+
 func setCmdsErr(cmds []Cmder, e error) {
 	for _, cmd := range cmds {
 		if cmd.Err() == nil {
@@ -44,8 +104,8 @@ func cmdsFirstErr(cmds []Cmder) error {
 	return nil
 }
 
-func writeCmds(wr *proto.Writer, cmds []Cmder) error {
-	if err := wr.WriteMetaFrame(len(cmds)); err != nil {
+func (c *baseClient) writeCmds(wr *proto.Writer, cmds []Cmder) error {
+	if err := c.writeMetaFrame(wr, len(cmds)); err != nil {
 		return err
 	}
 	for _, cmd := range cmds {
@@ -57,7 +117,88 @@ func writeCmds(wr *proto.Writer, cmds []Cmder) error {
 }
 
 func writeCmd(wr *proto.Writer, cmd Cmder) error {
-	return wr.WriteArgs(cmd.Args())
+	n := wr.Written()
+	err := wr.WriteArgs(cmd.Args())
+	cmd.addBytes(wr.Written()-n, 0)
+	return err
+}
+
+func readCmdReply(rd *proto.Reader, cmd Cmder) error {
+	n := rd.Consumed()
+	_ = rd.TakeAttrs() // attributes of a discarded reply
+	err := cmd.readReply(rd)
+	cmd.addBytes(0, rd.Consumed()-n)
+	if attrs := rd.TakeAttrs(); attrs != nil {
+		cmd.setAttrs(attrs)
+	}
+	return err
+}
+
+// writeCommands are the actions that change data or schema, by lower-cased
+// name. Options.ReadOnly rejects them.
+var writeCommands = map[string]bool{
+	"set":     true,
+	"uset":    true,
+	"update":  true,
+	"del":     true,
+	"mset":    true,
+	"mupdate": true,
+	"sset":    true,
+	"supdate": true,
+	"sdel":    true,
+	"pop":     true,
+	"mpop":    true,
+	"lset":    true,
+	"lmod":    true,
+	"flushdb": true,
+	"mksnap":  true,
+	"create":  true,
+	"drop":    true,
+}
+
+// keyLayout tells which arguments of a command are keys.
+type keyLayout int8
+
+const (
+	keyFirst keyLayout = iota + 1 // the first argument
+	keyAll                        // every argument
+	keyPairs                      // the first argument of key-value pairs
+)
+
+// keyLayouts are the layouts of the commands that take keys, by
+// lower-cased name. Options.EncodeBinaryKeys encodes these arguments.
+var keyLayouts = map[string]keyLayout{
+	"get":     keyFirst,
+	"set":     keyFirst,
+	"update":  keyFirst,
+	"keylen":  keyFirst,
+	"pop":     keyFirst,
+	"lget":    keyFirst,
+	"lset":    keyFirst,
+	"lmod":    keyFirst,
+	"del":     keyAll,
+	"exists":  keyAll,
+	"mget":    keyAll,
+	"mpop":    keyAll,
+	"sdel":    keyAll,
+	"mset":    keyPairs,
+	"mupdate": keyPairs,
+	"uset":    keyPairs,
+	"sset":    keyPairs,
+	"supdate": keyPairs,
+}
+
+// isWriteCmd reports whether cmd changes data, schema or users.
+func isWriteCmd(cmd Cmder) bool {
+	name := cmd.Name()
+	if name == "auth" {
+		switch internal.ToLower(cmd.stringArg(1)) {
+		case "adduser", "deluser", "claim", "restore":
+			return true
+		}
+		return false
+	}
+	return writeCommands[name]
 }
 
 func cmdString(cmd Cmder, val interface{}) string {
@@ -89,6 +230,16 @@ type baseCmd struct {
 	err    error
 	keyPos int8
 
+	nonIdempotent bool
+	keysEncoded   bool
+
+	bytesWritten int64
+	bytesRead    int64
+	attempts     int
+
+	attrs map[string]interface{}
+	meta  map[string]interface{}
+
 	_readTimeout *time.Duration
 }
 
@@ -143,6 +294,86 @@ func (cmd *baseCmd) SetFirstKeyPos(keyPos int8) {
 	cmd.keyPos = keyPos
 }
 
+func (cmd *baseCmd) Idempotent() bool {
+	return !cmd.nonIdempotent
+}
+
+// SetIdempotent marks whether the command can be retried after a timeout
+// that happened once the command was already sent. Commands are idempotent
+// by default.
+func (cmd *baseCmd) SetIdempotent(idempotent bool) {
+	cmd.nonIdempotent = !idempotent
+}
+
+func (cmd *baseCmd) BytesWritten() int64 {
+	return cmd.bytesWritten
+}
+
+func (cmd *baseCmd) BytesRead() int64 {
+	return cmd.bytesRead
+}
+
+func (cmd *baseCmd) addBytes(written, read int64) {
+	cmd.bytesWritten += written
+	cmd.bytesRead += read
+}
+
+func (cmd *baseCmd) Attempts() int {
+	return cmd.attempts
+}
+
+func (cmd *baseCmd) addAttempt() {
+	cmd.attempts++
+}
+
+// encodedKeyPrefix starts the keys encoded by Options.EncodeBinaryKeys,
+// so that listed keys written without the option are never decoded, even
+// if they happen to be valid base64.
+const encodedKeyPrefix = "\x00b64:"
+
+// encodeKeys base64-encodes the arguments of cmd that are keys, see
+// Options.EncodeBinaryKeys. The arguments are copied, as they may belong
+// to the caller, and are only encoded once.
+func (cmd *baseCmd) encodeKeys() {
+	layout := keyLayouts[cmd.Name()]
+	if layout == 0 || cmd.keysEncoded {
+		return
+	}
+	cmd.keysEncoded = true
+
+	args := make([]interface{}, len(cmd.args))
+	copy(args, cmd.args)
+	for i := 1; i < len(args); i++ {
+		if layout == keyFirst && i > 1 {
+			break
+		}
+		if layout == keyPairs && i%2 == 0 {
+			continue
+		}
+		args[i] = encodedKeyPrefix + base64.StdEncoding.EncodeToString(internal.AppendArg(nil, args[i]))
+	}
+	cmd.args = args
+}
+
+func (cmd *baseCmd) SetMeta(key string, val interface{}) {
+	if cmd.meta == nil {
+		cmd.meta = make(map[string]interface{})
+	}
+	cmd.meta[key] = val
+}
+
+func (cmd *baseCmd) Meta(key string) interface{} {
+	return cmd.meta[key]
+}
+
+func (cmd *baseCmd) Attrs() map[string]interface{} {
+	return cmd.attrs
+}
+
+func (cmd *baseCmd) setAttrs(attrs map[string]interface{}) {
+	cmd.attrs = attrs
+}
+
 func (cmd *baseCmd) SetErr(e error) {
 	cmd.err = e
 }
@@ -165,7 +396,8 @@ func (cmd *baseCmd) setReadTimeout(d time.Duration) {
 type Cmd struct {
 	baseCmd
 
-	val interface{}
+	val       interface{}
+	replyType byte
 }
 
 func NewCmd(ctx context.Context, args ...interface{}) *Cmd {
@@ -274,6 +506,8 @@ func toFloat32(val interface{}) (float32, error) {
 	switch val := val.(type) {
 	case int64:
 		return float32(val), nil
+	case float64:
+		return float32(val), nil
 	case string:
 		f, err := strconv.ParseFloat(val, 32)
 		if err != nil {
@@ -297,6 +531,8 @@ func toFloat64(val interface{}) (float64, error) {
 	switch val := val.(type) {
 	case int64:
 		return float64(val), nil
+	case float64:
+		return val, nil
 	case string:
 		return strconv.ParseFloat(val, 64)
 	default:
@@ -438,7 +674,17 @@ func (cmd *Cmd) BoolSlice() ([]bool, error) {
 	return bools, nil
 }
 
+// IsStatusOK reports whether the server replied with a success status code,
+// which Val reports as int64(0) just like an integer reply of 0.
+func (cmd *Cmd) IsStatusOK() bool {
+	return cmd.err == nil && cmd.replyType == proto.RespStatus
+}
+
 func (cmd *Cmd) readReply(rd *proto.Reader) (err error) {
+	cmd.replyType, err = rd.PeekReplyType()
+	if err != nil {
+		return err
+	}
 	cmd.val, err = rd.ReadReply()
 	return err
 }
@@ -482,6 +728,28 @@ func (cmd *IntCmd) String() string {
 	return cmdString(cmd, cmd.val)
 }
 
+// Human returns the value with its digits grouped in thousands, e.g.
+// "1,234,567" for a DbSize count. It is empty if the command failed.
+func (cmd *IntCmd) Human() string {
+	if cmd.err != nil {
+		return ""
+	}
+	s := strconv.FormatInt(cmd.val, 10)
+	start := 0
+	if cmd.val < 0 {
+		start = 1
+	}
+	b := make([]byte, 0, len(s)+(len(s)-start-1)/3)
+	b = append(b, s[:start]...)
+	for i := start; i < len(s); i++ {
+		if i > start && (len(s)-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
 func (cmd *IntCmd) readReply(rd *proto.Reader) (err error) {
 	cmd.val, err = rd.ReadInt()
 	return err
@@ -489,10 +757,30 @@ func (cmd *IntCmd) readReply(rd *proto.Reader) (err error) {
 
 // ------------------------------------------------------------------------------
 
+// discardCmd is sent by Fire: its reply is read and thrown away.
+type discardCmd struct {
+	baseCmd
+}
+
+var _ Cmder = (*discardCmd)(nil)
+
+func (cmd *discardCmd) String() string {
+	return cmdString(cmd, nil)
+}
+
+func (cmd *discardCmd) readReply(rd *proto.Reader) error {
+	return rd.DiscardNext()
+}
+
+// ------------------------------------------------------------------------------
+
 type SliceCmd struct {
 	baseCmd
 
 	val []interface{}
+
+	// wantLen is the number of elements the reply must hold, 0 if unknown.
+	wantLen int
 }
 
 var _ Cmder = (*SliceCmd)(nil)
@@ -522,9 +810,258 @@ func (cmd *SliceCmd) String() string {
 	return cmdString(cmd, cmd.val)
 }
 
+// Scan scans the results into dst, which must hold one destination per
+// element. Elements of missing keys leave their destination untouched.
+func (cmd *SliceCmd) Scan(dst ...interface{}) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	if len(dst) != len(cmd.val) {
+		return fmt.Errorf("skytable: got %d destinations for %d values", len(dst), len(cmd.val))
+	}
+
+	for i, v := range cmd.val {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case error:
+			return v
+		}
+		if err := scan(v, dst[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (cmd *SliceCmd) readReply(rd *proto.Reader) (err error) {
 	cmd.val, err = rd.ReadSlice()
-	return err
+	if err != nil {
+		return err
+	}
+	if cmd.wantLen > 0 && len(cmd.val) != cmd.wantLen {
+		return replyCountMismatch(len(cmd.val), cmd.wantLen)
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+
+type MapStringStringCmd struct {
+	baseCmd
+
+	val  map[string]string
+	keys []string
+}
+
+var _ Cmder = (*MapStringStringCmd)(nil)
+
+func NewMapStringStringCmd(ctx context.Context, args ...interface{}) *MapStringStringCmd {
+	return &MapStringStringCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *MapStringStringCmd) SetVal(val map[string]string) {
+	cmd.val = val
+}
+
+func (cmd *MapStringStringCmd) Val() map[string]string {
+	return cmd.val
+}
+
+func (cmd *MapStringStringCmd) Result() (map[string]string, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *MapStringStringCmd) String() string {
+	return cmdString(cmd, cmd.val)
+}
+
+// readReply maps the values of the reply to cmd.keys by position.
+// Missing keys are left out of the map.
+func (cmd *MapStringStringCmd) readReply(rd *proto.Reader) error {
+	vals, err := rd.ReadSlice()
+	if err != nil {
+		return err
+	}
+	if len(vals) != len(cmd.keys) {
+		return replyCountMismatch(len(vals), len(cmd.keys))
+	}
+
+	cmd.val = make(map[string]string, len(vals))
+	for i, v := range vals {
+		switch v := v.(type) {
+		case nil:
+		case string:
+			cmd.val[cmd.keys[i]] = v
+		case []byte:
+			cmd.val[cmd.keys[i]] = string(v)
+		case error:
+			return v
+		default:
+			return fmt.Errorf("skytable: unexpected type=%T for MapStringStringCmd value", v)
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+
+// ValueWithLen is the result of GetWithLen.
+type ValueWithLen struct {
+	Value  string
+	Length int64
+	// Exists is false when the key doesn't exist; Value and Length are
+	// then empty.
+	Exists bool
+}
+
+// GetWithLenCmd is returned by GetWithLen. It combines the replies of
+// GET and KEYLEN, so it can't be processed on its own.
+type GetWithLenCmd struct {
+	baseCmd
+
+	val ValueWithLen
+}
+
+func NewGetWithLenCmd(ctx context.Context, args ...interface{}) *GetWithLenCmd {
+	return &GetWithLenCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *GetWithLenCmd) SetVal(val ValueWithLen) {
+	cmd.val = val
+}
+
+func (cmd *GetWithLenCmd) Val() ValueWithLen {
+	return cmd.val
+}
+
+func (cmd *GetWithLenCmd) Result() (ValueWithLen, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *GetWithLenCmd) String() string {
+	if cmd.err != nil {
+		return fmt.Sprintf("%s: %s", cmd.FullName(), cmd.err)
+	}
+	return fmt.Sprintf("%s: %+v", cmd.FullName(), cmd.val)
+}
+
+// ------------------------------------------------------------------------------
+
+// KeyKind is the kind of values a keymap table holds.
+type KeyKind string
+
+const (
+	KeyKindScalar KeyKind = "scalar"
+	KeyKindList   KeyKind = "list"
+)
+
+// KeyTypeCmd is returned by KeyType. It is computed from the table
+// description, so it can't be processed on its own.
+type KeyTypeCmd struct {
+	baseCmd
+
+	val KeyKind
+}
+
+func NewKeyTypeCmd(ctx context.Context, args ...interface{}) *KeyTypeCmd {
+	return &KeyTypeCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *KeyTypeCmd) SetVal(val KeyKind) {
+	cmd.val = val
+}
+
+func (cmd *KeyTypeCmd) Val() KeyKind {
+	return cmd.val
+}
+
+func (cmd *KeyTypeCmd) Result() (KeyKind, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *KeyTypeCmd) String() string {
+	if cmd.err != nil {
+		return fmt.Sprintf("%s: %s", cmd.FullName(), cmd.err)
+	}
+	return fmt.Sprintf("%s: %s", cmd.FullName(), cmd.val)
+}
+
+// ------------------------------------------------------------------------------
+
+// AuthStatus is the result of AuthStatus.
+type AuthStatus struct {
+	// Enabled is false when authn is disabled on the server.
+	Enabled bool
+	// Claimed is true when the connection is logged in, which proves that
+	// the root account was claimed. It is false when the connection is not
+	// logged in, whether root was claimed or not.
+	Claimed bool
+}
+
+type AuthStatusCmd struct {
+	baseCmd
+
+	val AuthStatus
+}
+
+var _ Cmder = (*AuthStatusCmd)(nil)
+
+func NewAuthStatusCmd(ctx context.Context, args ...interface{}) *AuthStatusCmd {
+	return &AuthStatusCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *AuthStatusCmd) SetVal(val AuthStatus) {
+	cmd.val = val
+}
+
+func (cmd *AuthStatusCmd) Val() AuthStatus {
+	return cmd.val
+}
+
+func (cmd *AuthStatusCmd) Result() (AuthStatus, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *AuthStatusCmd) String() string {
+	return cmdString(cmd, fmt.Sprintf("%+v", cmd.val))
+}
+
+// readReply reads the reply of AUTH WHOAMI. The auth errors it can return
+// describe the auth status, so they are not returned.
+func (cmd *AuthStatusCmd) readReply(rd *proto.Reader) error {
+	_, err := rd.ReadString()
+	switch err {
+	case nil:
+		cmd.val = AuthStatus{Enabled: true, Claimed: true}
+	case AuthDisabledError:
+		cmd.val = AuthStatus{}
+	case AuthnRealmError, BadCredentials:
+		cmd.val = AuthStatus{Enabled: true}
+	default:
+		return err
+	}
+	return nil
 }
 
 // ------------------------------------------------------------------------------
@@ -558,6 +1095,12 @@ func (cmd *StatusCmd) Result() (int64, error) {
 	return cmd.val, cmd.err
 }
 
+// Code returns the raw status code sent by the server: 0 on success,
+// otherwise the code behind Err, e.g. 2 for OverwriteError.
+func (cmd *StatusCmd) Code() int64 {
+	return cmd.val
+}
+
 func (cmd *StatusCmd) String() string {
 	return cmdString(cmd, cmd.val)
 }
@@ -598,6 +1141,9 @@ func (cmd *StringCmd) Result() (string, error) {
 	return cmd.Val(), cmd.err
 }
 
+// Bytes returns the value without copying it, so it must not be modified.
+// The value is read into a buffer of its own, so binary values are kept
+// byte for byte and later replies never overwrite it.
 func (cmd *StringCmd) Bytes() ([]byte, error) {
 	return util.StringToBytes(cmd.val), cmd.err
 }
@@ -606,7 +1152,7 @@ func (cmd *StringCmd) Bool() (bool, error) {
 	if cmd.err != nil {
 		return false, cmd.err
 	}
-	return strconv.ParseBool(cmd.val)
+	return strconv.ParseBool(cmd.Val())
 }
 
 func (cmd *StringCmd) Int() (int, error) {
@@ -641,6 +1187,9 @@ func (cmd *StringCmd) Float32() (float32, error) {
 	return float32(f), nil
 }
 
+// Float64 parses the value as a float64. Floats are written on the wire in
+// their shortest exact decimal form, so a float64 that was stored by this
+// client is read back bit for bit.
 func (cmd *StringCmd) Float64() (float64, error) {
 	if cmd.err != nil {
 		return 0, cmd.err
@@ -648,6 +1197,14 @@ func (cmd *StringCmd) Float64() (float64, error) {
 	return strconv.ParseFloat(cmd.Val(), 64)
 }
 
+// Scan stores the value in val, calling val.ScanSkytable if val implements Scanner.
+func (cmd *StringCmd) Scan(val interface{}) error {
+	if cmd.err != nil {
+		return cmd.err
+	}
+	return scan(cmd.Val(), val)
+}
+
 func (cmd *StringCmd) Time() (time.Time, error) {
 	if cmd.err != nil {
 		return time.Time{}, cmd.err
@@ -656,7 +1213,7 @@ func (cmd *StringCmd) Time() (time.Time, error) {
 }
 
 func (cmd *StringCmd) String() string {
-	return cmdString(cmd, cmd.val)
+	return cmdString(cmd, cmd.Val())
 }
 
 func (cmd *StringCmd) readReply(rd *proto.Reader) (err error) {
@@ -666,10 +1223,21 @@ func (cmd *StringCmd) readReply(rd *proto.Reader) (err error) {
 
 //------------------------------------------------------------------------------
 
+// StringSliceCmd holds an array of strings. Elements that are Nil or
+// another error in the reply, like the missing keys of MPOP, are stored
+// as empty strings; Present tells them apart from empty values.
 type StringSliceCmd struct {
 	baseCmd
 
 	val []string
+	// missing is nil unless an element was missing.
+	missing []bool
+	// decodeKeys makes readReply decode the elements that are keys
+	// encoded by Options.EncodeBinaryKeys.
+	decodeKeys bool
+	// skipExpiryKeys makes readReply leave out the keys SetEx uses to
+	// emulate expiry.
+	skipExpiryKeys bool
 }
 
 var _ Cmder = (*StringSliceCmd)(nil)
@@ -685,6 +1253,7 @@ func NewStringSliceCmd(ctx context.Context, args ...interface{}) *StringSliceCmd
 
 func (cmd *StringSliceCmd) SetVal(val []string) {
 	cmd.val = val
+	cmd.missing = nil
 }
 
 func (cmd *StringSliceCmd) Val() []string {
@@ -699,21 +1268,78 @@ func (cmd *StringSliceCmd) String() string {
 	return cmdString(cmd, cmd.val)
 }
 
+// Present reports whether element i of the reply held a value, as
+// opposed to Nil or another error.
+func (cmd *StringSliceCmd) Present(i int) bool {
+	if i < 0 || i >= len(cmd.val) {
+		return false
+	}
+	return cmd.missing == nil || !cmd.missing[i]
+}
+
+// Len returns the number of elements of the reply, missing ones included.
+func (cmd *StringSliceCmd) Len() int {
+	return len(cmd.val)
+}
+
+// At returns element i of the reply. ok is false, and s empty, if i is
+// out of range or the element is missing, see Present.
+func (cmd *StringSliceCmd) At(i int) (s string, ok bool) {
+	if !cmd.Present(i) {
+		return "", false
+	}
+	return cmd.val[i], true
+}
+
+// Contains reports whether s is one of the elements of the reply.
+// Missing elements match nothing, not even "".
+func (cmd *StringSliceCmd) Contains(s string) bool {
+	for i, v := range cmd.val {
+		if v == s && cmd.Present(i) {
+			return true
+		}
+	}
+	return false
+}
+
 func (cmd *StringSliceCmd) readReply(rd *proto.Reader) error {
 	n, err := rd.ReadArrayLen()
 	if err != nil {
 		return err
 	}
-	cmd.val = make([]string, n)
-	for i := 0; i < len(cmd.val); i++ {
-		switch s, err := rd.ReadString(); {
-		case err == Nil:
-			cmd.val[i] = ""
-		case err != nil:
-			return err
-		default:
-			cmd.val[i] = s
+	// n is only trusted as far as the elements actually arrive.
+	cmd.val = make([]string, 0, proto.PreallocLen(n))
+	cmd.missing = nil
+
+	// Every element is read, even after an error element, so that the
+	// connection stays in sync. The first error other than Nil is returned.
+	var firstErr error
+	for i := 0; i < n; i++ {
+		s, err := rd.ReadString()
+		if err != nil {
+			if !isSkytableError(err) {
+				return err
+			}
+			if err != Nil && firstErr == nil {
+				firstErr = err
+			}
+			if cmd.missing == nil {
+				cmd.missing = make([]bool, len(cmd.val), proto.PreallocLen(n))
+			}
+		}
+		if cmd.decodeKeys && err == nil && strings.HasPrefix(s, encodedKeyPrefix) {
+			// Keys written without the option are kept as they are.
+			if b, err := base64.StdEncoding.DecodeString(s[len(encodedKeyPrefix):]); err == nil {
+				s = string(b)
+			}
+		}
+		if cmd.skipExpiryKeys && err == nil && isExpiryKey(s) {
+			continue
+		}
+		cmd.val = append(cmd.val, s)
+		if cmd.missing != nil {
+			cmd.missing = append(cmd.missing, err != nil)
 		}
 	}
-	return nil
+	return firstErr
 }
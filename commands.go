@@ -2,7 +2,12 @@ package skytable
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/satvik007/skytable-go/internal/proto"
 )
 
 type Cmdable interface {
@@ -10,18 +15,22 @@ type Cmdable interface {
 	Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error)
 
 	AddUser(ctx context.Context, username string) *StringCmd
+	AuthStatus(ctx context.Context) *AuthStatusCmd
 	Claim(ctx context.Context, originKey string) *StringCmd
 	CreateKeyspace(ctx context.Context, entity string) *StatusCmd
 	CreateTable(ctx context.Context, table, model string, modelArgs []string, properties ...string) *StatusCmd
+	CreateListTable(ctx context.Context, table string, keyType, elemType DataType, properties ...string) *StatusCmd
 	DbSize(ctx context.Context, entity string) *IntCmd
 	Del(ctx context.Context, keys ...string) *IntCmd
 	DelUser(ctx context.Context, username string) *StatusCmd
 	DropKeyspace(ctx context.Context, keyspace string) *StatusCmd
 	DropTable(ctx context.Context, table string) *StatusCmd
+	Echo(ctx context.Context, payload string) *StringCmd
 	Exists(ctx context.Context, keys ...string) *IntCmd
 	FlushDB(ctx context.Context, entity string) *StatusCmd
 	Get(ctx context.Context, key string) *StringCmd
 	Heya(ctx context.Context, message string) *StringCmd
+	Inspect(ctx context.Context, target string, args ...string) *StringSliceCmd
 	InspectKeyspace(ctx context.Context, keyspace string) *StringSliceCmd
 	InspectKeyspaces(ctx context.Context) *StringSliceCmd
 	InspectTable(ctx context.Context, table string) *StringSliceCmd
@@ -42,6 +51,7 @@ type Cmdable interface {
 	LSet(ctx context.Context, key string, values ...interface{}) *StatusCmd
 	LSKeys(ctx context.Context, entity string, limit int) *StringSliceCmd
 	MGet(ctx context.Context, keys ...interface{}) *SliceCmd
+	MGetMap(ctx context.Context, keys ...string) *MapStringStringCmd
 	MKSnap(ctx context.Context, snapName string) *StatusCmd
 	MPop(ctx context.Context, keys ...interface{}) *StringSliceCmd
 	MSet(ctx context.Context, keyValuePairs ...interface{}) *IntCmd
@@ -49,9 +59,12 @@ type Cmdable interface {
 	Pop(ctx context.Context, key string) *StringCmd
 	Restore(ctx context.Context, originKey string, username string) *StringCmd
 	SDel(ctx context.Context, keys ...interface{}) *StatusCmd
+	SDelKeys(ctx context.Context, keys []string) *StatusCmd
 	Set(ctx context.Context, key interface{}, value interface{}) *StatusCmd
 	SSet(ctx context.Context, keyValuePairs ...interface{}) *StatusCmd
+	SSetMap(ctx context.Context, m map[string]interface{}) *StatusCmd
 	SUpdate(ctx context.Context, keyValuePairs ...interface{}) *StatusCmd
+	SUpdateMap(ctx context.Context, m map[string]interface{}) *StatusCmd
 	SysInfo(ctx context.Context, property string) *StringCmd
 	SysMetric(ctx context.Context, metric string) *StringCmd
 	Update(ctx context.Context, key interface{}, value interface{}) *StatusCmd
@@ -67,14 +80,73 @@ type StatefulCmdable interface {
 	Logout(ctx context.Context) *StatusCmd
 }
 
+// KV is the subset of Cmdable for reading and writing keys. Application
+// code can depend on it instead of *Client, and use the in-memory
+// skytabletest.FakeKV in tests.
+type KV interface {
+	Get(ctx context.Context, key string) *StringCmd
+	Set(ctx context.Context, key interface{}, value interface{}) *StatusCmd
+	Update(ctx context.Context, key interface{}, value interface{}) *StatusCmd
+	Del(ctx context.Context, keys ...string) *IntCmd
+	Exists(ctx context.Context, keys ...string) *IntCmd
+	MGet(ctx context.Context, keys ...interface{}) *SliceCmd
+	MSet(ctx context.Context, keyValuePairs ...interface{}) *IntCmd
+}
+
 var (
 	_ Cmdable = (*Client)(nil)
+	_ Cmdable = (*Session)(nil)
+	_ KV      = (*Client)(nil)
 )
 
 type cmdable func(ctx context.Context, cmd Cmder) error
 
 type statefulCmdable func(ctx context.Context, cmd Cmder) error
 
+func appendArgs(dst, src []interface{}) []interface{} {
+	if len(src) == 1 {
+		return appendArg(dst, src[0])
+	}
+
+	dst = append(dst, src...)
+	return dst
+}
+
+func appendArg(dst []interface{}, arg interface{}) []interface{} {
+	switch arg := arg.(type) {
+	case []string:
+		for _, s := range arg {
+			dst = append(dst, s)
+		}
+		return dst
+	case []interface{}:
+		dst = append(dst, arg...)
+		return dst
+	case map[string]interface{}:
+		for k, v := range arg {
+			dst = append(dst, k, v)
+		}
+		return dst
+	case map[string]string:
+		for k, v := range arg {
+			dst = append(dst, k, v)
+		}
+		return dst
+	default:
+		return append(dst, arg)
+	}
+}
+
+// RawArg is a command argument sent to the server exactly as given.
+type RawArg = proto.RawArg
+
+// Raw wraps b so it is written as a single argument byte-for-byte, without
+// the type inspection done for other arguments. The caller is responsible
+// for its correctness.
+func Raw(b []byte) RawArg {
+	return RawArg(b)
+}
+
 // ------------------------------------------------------------------------------
 
 // Login Attempts to log in using the provided credentials
@@ -113,6 +185,17 @@ func (c cmdable) AddUser(ctx context.Context, username string) *StringCmd {
 	return cmd
 }
 
+// AuthStatus Reports whether authn is enabled on the server and whether the
+// root account is claimed, using AUTH WHOAMI. Setup tools can use it to
+// decide whether to call Claim.
+//
+// Time complexity: O(1)
+func (c cmdable) AuthStatus(ctx context.Context) *AuthStatusCmd {
+	cmd := NewAuthStatusCmd(ctx, "AUTH", "WHOAMI")
+	_ = c(ctx, cmd)
+	return cmd
+}
+
 // Claim Attempts to claim the root account using the origin key.
 //
 // Time complexity: O(1)
@@ -157,9 +240,14 @@ func (c cmdable) CreateKeyspace(ctx context.Context, entity string) *StatusCmd {
 //
 // CREATE TABLE <entity> keymap(<type>,<type>) <properties>
 //
+// The only property is "volatile". More than one property fails with
+// TooManyArgsError and any other with UnknownPropertyError, both without
+// calling the server; errors.Is matches them like the server's errors.
+//
 // Operation can throw error.
 //   - string "err-already-exists" if it already existed
 //   - string "default-container-unset" if the connection level default keyspace has not been set
+//   - string "too-many-args" or "unknown-property" for invalid properties
 //   - 5	Server error	An error occurred on the server side
 func (c cmdable) CreateTable(ctx context.Context, table, model string, modelArgs []string, properties ...string) *StatusCmd {
 	args := make([]interface{}, 4, len(properties)+4)
@@ -171,10 +259,65 @@ func (c cmdable) CreateTable(ctx context.Context, table, model string, modelArgs
 		args = append(args, prop)
 	}
 	cmd := NewStatusCmd(ctx, args...)
+	if err := checkTableProperties(properties); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
 	_ = c(ctx, cmd)
 	return cmd
 }
 
+// tableProperties are the properties CREATE TABLE accepts.
+var tableProperties = map[string]bool{"volatile": true}
+
+func checkTableProperties(properties []string) error {
+	if len(properties) > len(tableProperties) {
+		return fmt.Errorf("%w: got %d table properties, wanted at most %d",
+			TooManyArgsError, len(properties), len(tableProperties))
+	}
+	for _, prop := range properties {
+		if !tableProperties[prop] {
+			return fmt.Errorf("%w: %q", UnknownPropertyError, prop)
+		}
+	}
+	return nil
+}
+
+// DataType is a type of the keys or values of a keymap table.
+type DataType string
+
+const (
+	DataTypeStr    DataType = "str"
+	DataTypeBinstr DataType = "binstr"
+	DataTypeList   DataType = "list"
+)
+
+// CreateListTable Creates a keymap table mapping keys of keyType to lists
+// of elemType values, i.e. keymap(<keyType>,list<elemType>), as used by the
+// LGet, LSet and LMod commands.
+//
+// keyType and elemType must be DataTypeStr or DataTypeBinstr: lists can't
+// be nested or used as keys. Other types fail without calling the server.
+//
+// Operation can throw error.
+//   - string "err-already-exists" if it already existed
+//   - string "default-container-unset" if the connection level default keyspace has not been set
+func (c cmdable) CreateListTable(
+	ctx context.Context, table string, keyType, elemType DataType, properties ...string,
+) *StatusCmd {
+	model := "list<" + string(elemType) + ">"
+	if !isScalarType(keyType) || !isScalarType(elemType) {
+		cmd := NewStatusCmd(ctx, "CREATE", "TABLE", table, "keymap("+string(keyType)+","+model+")")
+		cmd.SetErr(fmt.Errorf("skytable: can't create keymap(%s,%s): types must be str or binstr", keyType, model))
+		return cmd
+	}
+	return c.CreateTable(ctx, table, "keymap", []string{string(keyType), model}, properties...)
+}
+
+func isScalarType(t DataType) bool {
+	return t == DataTypeStr || t == DataTypeBinstr
+}
+
 // DbSize Check the number of entries stored in the current table or in the provided entity.
 //
 // Time complexity: O(1)
@@ -200,7 +343,9 @@ func (c cmdable) DbSize(ctx context.Context, entity string) *IntCmd {
 func (c cmdable) Del(ctx context.Context, keys ...string) *IntCmd {
 	args := make([]interface{}, 1, 1+len(keys))
 	args[0] = "DEL"
-	args = append(args, keys)
+	for _, key := range keys {
+		args = append(args, key)
+	}
 	cmd := NewIntCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -252,7 +397,9 @@ func (c cmdable) DropTable(ctx context.Context, table string) *StatusCmd {
 func (c cmdable) Exists(ctx context.Context, keys ...string) *IntCmd {
 	args := make([]interface{}, 1, 1+len(keys))
 	args[0] = "EXISTS"
-	args = append(args, keys)
+	for _, key := range keys {
+		args = append(args, key)
+	}
 	cmd := NewIntCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -287,6 +434,9 @@ func (c cmdable) FlushDB(ctx context.Context, entity string) *StatusCmd {
 	}
 
 	cmd := NewStatusCmd(ctx, args...)
+	// A flush that timed out is likely still running on the server:
+	// don't pile up another one.
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -349,6 +499,33 @@ func (c cmdable) Heya(ctx context.Context, message string) *StringCmd {
 	return cmd
 }
 
+// Echo Returns payload as sent, using HEYA. Unlike Heya, an empty payload
+// is sent as-is, so the reply is always the payload.
+//
+// Time complexity: O(1)
+func (c cmdable) Echo(ctx context.Context, payload string) *StringCmd {
+	cmd := NewStringCmd(ctx, "HEYA", payload)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// Inspect Runs INSPECT <target> <args...>, for inspect queries without
+// a dedicated method, e.g. ones added by newer servers.
+//
+// Operation can throw error.
+//   - string "unknown-inspect-query" as UnknownInspectQueryError
+func (c cmdable) Inspect(ctx context.Context, target string, args ...string) *StringSliceCmd {
+	cmdArgs := make([]interface{}, 2, 2+len(args))
+	cmdArgs[0] = "INSPECT"
+	cmdArgs[1] = target
+	for _, arg := range args {
+		cmdArgs = append(cmdArgs, arg)
+	}
+	cmd := NewStringSliceCmd(ctx, cmdArgs...)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
 // InspectKeyspace This will return a flat array with all the table names
 // passing keyspace as empty string "" will return all the table names in current keyspace
 func (c cmdable) InspectKeyspace(ctx context.Context, keyspace string) *StringSliceCmd {
@@ -503,6 +680,7 @@ func (c cmdable) LModPush(ctx context.Context, key string, elements ...interface
 	args = append(args, "LMOD", key, "push")
 	args = append(args, elements...)
 	cmd := NewStatusCmd(ctx, args...)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -518,6 +696,7 @@ func (c cmdable) LModPush(ctx context.Context, key string, elements ...interface
 //	- string "bad-list-index"	The index is out of range
 func (c cmdable) LModInsert(ctx context.Context, key string, index int, value interface{}) *StatusCmd {
 	cmd := NewStatusCmd(ctx, "LMOD", key, "insert", index, value)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -538,6 +717,7 @@ func (c cmdable) LModPop(ctx context.Context, key string, index int) *StringCmd
 		args = append(args, index)
 	}
 	cmd := NewStringCmd(ctx, args...)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -552,6 +732,7 @@ func (c cmdable) LModPop(ctx context.Context, key string, index int) *StringCmd
 //  - string "bad-list-index"	The index is out of range
 func (c cmdable) LModRemove(ctx context.Context, key string, index int) *StatusCmd {
 	cmd := NewStatusCmd(ctx, "LMOD", key, "remove", index)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -608,12 +789,37 @@ func (c cmdable) LSKeys(ctx context.Context, entity string, limit int) *StringSl
 }
 
 // MGet Get the value of 'n' keys from the current table, if they exist.
+// A reply holding a different number of values than keys fails
+// with ErrReplyCountMismatch.
 //
 // Time complexity: O(n)
 func (c cmdable) MGet(ctx context.Context, keys ...interface{}) *SliceCmd {
 	args := make([]interface{}, 0, 1+len(keys))
-	args = append(args, "MGET", keys)
+	args = append(args, "MGET")
+	args = appendArgs(args, keys)
 	cmd := NewSliceCmd(ctx, args...)
+	cmd.wantLen = len(args) - 1
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// MGetMap Get the value of 'n' keys from the current table as a map,
+// leaving out the keys that don't exist.
+//
+// The server returns one value per key in the order of the keys; a reply
+// with a different number of values fails with ErrReplyCountMismatch instead
+// of being mapped to the wrong keys. The reply doesn't carry the keys, so
+// a reordered reply of the right length can't be detected.
+//
+// Time complexity: O(n)
+func (c cmdable) MGetMap(ctx context.Context, keys ...string) *MapStringStringCmd {
+	args := make([]interface{}, 1, 1+len(keys))
+	args[0] = "MGET"
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	cmd := NewMapStringStringCmd(ctx, args...)
+	cmd.keys = keys
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -650,8 +856,10 @@ func (c cmdable) MKSnap(ctx context.Context, snapName string) *StatusCmd {
 // 	- 5	Server error	An error occurred on the server side
 func (c cmdable) MPop(ctx context.Context, keys ...interface{}) *StringSliceCmd {
 	args := make([]interface{}, 0, 1+len(keys))
-	args = append(args, "MOP", keys)
+	args = append(args, "MPOP")
+	args = appendArgs(args, keys)
 	cmd := NewStringSliceCmd(ctx, args...)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -665,7 +873,8 @@ func (c cmdable) MPop(ctx context.Context, keys ...interface{}) *StringSliceCmd
 // 	- 5	Server error	An error occurred on the server side
 func (c cmdable) MSet(ctx context.Context, keyValuePairs ...interface{}) *IntCmd {
 	args := make([]interface{}, 0, 1+len(keyValuePairs))
-	args = append(args, "MSET", keyValuePairs)
+	args = append(args, "MSET")
+	args = appendArgs(args, keyValuePairs)
 	cmd := NewIntCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -680,7 +889,8 @@ func (c cmdable) MSet(ctx context.Context, keyValuePairs ...interface{}) *IntCmd
 // 	- 5	Server error	An error occurred on the server side
 func (c cmdable) MUpdate(ctx context.Context, keyValuePairs ...interface{}) *IntCmd {
 	args := make([]interface{}, 0, 1+len(keyValuePairs))
-	args = append(args, "MUPDATE", keyValuePairs)
+	args = append(args, "MUPDATE")
+	args = appendArgs(args, keyValuePairs)
 	cmd := NewIntCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -695,6 +905,7 @@ func (c cmdable) MUpdate(ctx context.Context, keyValuePairs ...interface{}) *Int
 // 	- 5	Server error	An error occurred on the server side
 func (c cmdable) Pop(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(ctx, "POP", key)
+	cmd.SetIdempotent(false)
 	_ = c(ctx, cmd)
 	return cmd
 }
@@ -730,7 +941,8 @@ func (c cmdable) Restore(ctx context.Context, originKey, username string) *Strin
 // 	- 5	Server error	An error occurred on the server side
 func (c cmdable) SDel(ctx context.Context, keys ...interface{}) *StatusCmd {
 	args := make([]interface{}, 0, 1+len(keys))
-	args = append(args, "SDEL", keys)
+	args = append(args, "SDEL")
+	args = appendArgs(args, keys)
 	cmd := NewStatusCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -776,7 +988,8 @@ func (c cmdable) Set(ctx context.Context, key interface{}, value interface{}) *S
 // - 5	Server error	  An error occurred on the server side
 func (c cmdable) SSet(ctx context.Context, keyValuePairs ...interface{}) *StatusCmd {
 	args := make([]interface{}, 0, 1+len(keyValuePairs))
-	args = append(args, "SSET", keyValuePairs)
+	args = append(args, "SSET")
+	args = appendArgs(args, keyValuePairs)
 	cmd := NewStatusCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -792,12 +1005,75 @@ func (c cmdable) SSet(ctx context.Context, keyValuePairs ...interface{}) *Status
 // - 5	Server error	An error occurred on the server side
 func (c cmdable) SUpdate(ctx context.Context, keyValuePairs ...interface{}) *StatusCmd {
 	args := make([]interface{}, 0, 1+len(keyValuePairs))
-	args = append(args, "SUPDATE", keyValuePairs)
+	args = append(args, "SUPDATE")
+	args = appendArgs(args, keyValuePairs)
+	cmd := NewStatusCmd(ctx, args...)
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// SDelKeys is SDel for a list of keys. An empty list or an empty key
+// fails without calling the server.
+func (c cmdable) SDelKeys(ctx context.Context, keys []string) *StatusCmd {
+	args := make([]interface{}, 0, 1+len(keys))
+	args = append(args, "SDEL")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	cmd := NewStatusCmd(ctx, args...)
+	if err := checkKeys("SDEL", keys); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	_ = c(ctx, cmd)
+	return cmd
+}
+
+// SSetMap is SSet for the pairs of m, sent in key order. An empty map or
+// an empty key fails without calling the server.
+func (c cmdable) SSetMap(ctx context.Context, m map[string]interface{}) *StatusCmd {
+	return c.mapCmd(ctx, "SSET", m)
+}
+
+// SUpdateMap is SUpdate for the pairs of m, sent in key order. An empty
+// map or an empty key fails without calling the server.
+func (c cmdable) SUpdateMap(ctx context.Context, m map[string]interface{}) *StatusCmd {
+	return c.mapCmd(ctx, "SUPDATE", m)
+}
+
+func (c cmdable) mapCmd(ctx context.Context, name string, m map[string]interface{}) *StatusCmd {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]interface{}, 0, 1+2*len(keys))
+	args = append(args, name)
+	for _, key := range keys {
+		args = append(args, key, m[key])
+	}
 	cmd := NewStatusCmd(ctx, args...)
+	if err := checkKeys(name, keys); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
 	_ = c(ctx, cmd)
 	return cmd
 }
 
+func checkKeys(name string, keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("skytable: %s needs at least one key", name)
+	}
+	for _, key := range keys {
+		if key == "" {
+			return errors.New("skytable: keys can't be empty")
+		}
+	}
+	return nil
+}
+
 // SysInfo Returns static properties of the system, i.e properties that do not change during runtime.
 //
 // The following properties are available:
@@ -904,7 +1180,8 @@ func (c cmdable) Use(ctx context.Context, entity string) *StatusCmd {
 // - 5  Server error	 An error occurred on the server side
 func (c cmdable) USet(ctx context.Context, keyValuePairs ...interface{}) *IntCmd {
 	args := make([]interface{}, 0, 1+len(keyValuePairs))
-	args = append(args, "USET", keyValuePairs)
+	args = append(args, "USET")
+	args = appendArgs(args, keyValuePairs)
 	cmd := NewIntCmd(ctx, args...)
 	_ = c(ctx, cmd)
 	return cmd
@@ -0,0 +1,176 @@
+package skytable
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// Skytable has no native INCR, so counters are emulated with a
+// read-modify-write loop serialized per key within the Client.
+
+const keyLockStripes = 64
+
+// keyLocker serializes read-modify-write operations issued by one Client
+// on the same key. Keys are hashed onto a fixed number of stripes.
+type keyLocker struct {
+	stripes [keyLockStripes]sync.Mutex
+}
+
+func (l *keyLocker) lock(key string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	mu := &l.stripes[h.Sum32()%keyLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// rmwFunc computes the new value of a key from its current value.
+// exists reports whether the key was present. A nil value leaves the key
+// untouched.
+type rmwFunc func(val string, exists bool) (interface{}, error)
+
+// readModifyWrite reads key, passes its value to fn and writes the result back.
+// A missing key is created with SET; if another writer creates it first,
+// the loop starts over with the freshly stored value. Likewise, a key deleted
+// between the read and the UPDATE is recreated on the next iteration.
+// The commands go through the Client, hooks included; only the key lock
+// makes the loop atomic, and only against the callers of this Client.
+func (c *Client) readModifyWrite(ctx context.Context, key string, fn rmwFunc) error {
+	defer c.keyLocks.lock(key)()
+
+	for {
+		val, err := c.Get(ctx, key).Result()
+		exists := err == nil
+		if err != nil && err != Nil {
+			return err
+		}
+
+		newVal, err := fn(val, exists)
+		if err != nil {
+			return err
+		}
+		if newVal == nil {
+			return nil
+		}
+
+		if !exists {
+			err = c.Set(ctx, key, newVal).Err()
+			if err == OverwriteError {
+				continue
+			}
+			return err
+		}
+
+		err = c.Update(ctx, key, newVal).Err()
+		if err == Nil {
+			continue
+		}
+		return err
+	}
+}
+
+// Incr adds delta to the integer stored at key and returns the new value.
+// A missing key is created with the value delta.
+//
+// Concurrent Incr calls on the same key made through the same Client are
+// serialized, so they never lose updates: atomicity only holds within one
+// process. Skytable has no compare-and-set primitive, therefore writers in
+// other processes (or other Clients) that modify the key between the read
+// and the write can still cause a lost update.
+// Under heavy contention on one key, callers queue behind each other and
+// every increment costs two round trips.
+//
+// Operation can throw error.
+//   - strconv.ErrSyntax if the stored value is not an integer
+//   - strconv.ErrRange if the result overflows an int64; the key is left untouched
+func (c *Client) Incr(ctx context.Context, key string, delta int64) (int64, error) {
+	var n int64
+	err := c.readModifyWrite(ctx, key, func(val string, exists bool) (interface{}, error) {
+		n = delta
+		if exists {
+			cur, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if delta > 0 && cur > math.MaxInt64-delta || delta < 0 && cur < math.MinInt64-delta {
+				return nil, fmt.Errorf("skytable: Incr %d by %d: %w", cur, delta, strconv.ErrRange)
+			}
+			n += cur
+		}
+		return n, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Decr subtracts delta from the integer stored at key and returns the new value.
+// A missing key is created with the value -delta.
+// It shares the atomicity guarantees of Incr.
+//
+// math.MinInt64 can't be negated, so it is rejected with an error
+// wrapping strconv.ErrRange and the key is left untouched.
+func (c *Client) Decr(ctx context.Context, key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, fmt.Errorf("skytable: Decr by %d: %w", delta, strconv.ErrRange)
+	}
+	return c.Incr(ctx, key, -delta)
+}
+
+// IncrByFloat adds delta to the float stored at key and returns the new value.
+// A missing key is created with the value delta.
+//
+// The result is stored in the shortest decimal form that parses back to the
+// exact same float64 (strconv.FormatFloat with 'f' and precision -1), which is
+// also how float arguments are written on the wire, so repeated reads and
+// writes never drift. It shares the atomicity guarantees of Incr.
+func (c *Client) IncrByFloat(ctx context.Context, key string, delta float64) (float64, error) {
+	var f float64
+	err := c.readModifyWrite(ctx, key, func(val string, exists bool) (interface{}, error) {
+		f = delta
+		if exists {
+			cur, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, err
+			}
+			f += cur
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return f, nil
+}
+
+// KeyAbsent can be passed as the old value to CompareAndSwap to swap only
+// if the key does not exist yet.
+const KeyAbsent = "\x00skytable:absent\x00"
+
+// CompareAndSwap sets key to newVal only if its current value equals oldVal
+// and reports whether the swap happened. A missing key never matches unless
+// oldVal is KeyAbsent, in which case the key is created.
+// It shares the atomicity guarantees of Incr.
+func (c *Client) CompareAndSwap(ctx context.Context, key, oldVal, newVal string) (bool, error) {
+	var swapped bool
+	err := c.readModifyWrite(ctx, key, func(val string, exists bool) (interface{}, error) {
+		if exists {
+			swapped = oldVal != KeyAbsent && val == oldVal
+		} else {
+			swapped = oldVal == KeyAbsent
+		}
+		if !swapped {
+			return nil, nil
+		}
+		return newVal, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return swapped, nil
+}
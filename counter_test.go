@@ -0,0 +1,181 @@
+package skytable_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestDecrMinInt64(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if _, err := rdb.Decr(ctx, "counter", math.MinInt64); !errors.Is(err, strconv.ErrRange) {
+		t.Fatalf("got %v, wanted %v", err, strconv.ErrRange)
+	}
+	if n := len(srv.Commands()); n != 0 {
+		t.Fatalf("got %d commands, wanted none", n)
+	}
+
+	// The largest delta that can be negated still works.
+	n, err := rdb.Decr(ctx, "counter", math.MaxInt64)
+	if err != nil || n != -math.MaxInt64 {
+		t.Fatalf("got %d, %v, wanted %d", n, err, int64(-math.MaxInt64))
+	}
+}
+
+func TestIncrOverflow(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var cmds []string
+	rdb.AddHook(&hook{
+		afterProcess: func(ctx context.Context, cmd skytable.Cmder) error {
+			cmds = append(cmds, cmd.Name())
+			return nil
+		},
+	})
+
+	maxVal := strconv.FormatInt(math.MaxInt64, 10)
+	minVal := strconv.FormatInt(math.MinInt64, 10)
+	kv.handle([]string{"SET", "max", maxVal})
+	kv.handle([]string{"SET", "min", minVal})
+
+	for _, tt := range []struct {
+		key   string
+		incr  func() (int64, error)
+		value string
+	}{
+		{"max", func() (int64, error) { return rdb.Incr(ctx, "max", 1) }, maxVal},
+		{"min", func() (int64, error) { return rdb.Incr(ctx, "min", -1) }, minVal},
+		{"min", func() (int64, error) { return rdb.Decr(ctx, "min", 1) }, minVal},
+	} {
+		if _, err := tt.incr(); !errors.Is(err, strconv.ErrRange) {
+			t.Fatalf("%s: got %v, wanted %v", tt.key, err, strconv.ErrRange)
+		}
+		if val, _ := kv.Get(tt.key); val != tt.value {
+			t.Fatalf("%s: got %q, wanted it untouched", tt.key, val)
+		}
+	}
+
+	// The bounds themselves can be reached.
+	if n, err := rdb.Incr(ctx, "max", -1); err != nil || n != math.MaxInt64-1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if n, err := rdb.Incr(ctx, "max", 1); err != nil || n != math.MaxInt64 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+
+	// The commands go through the client and its hooks.
+	if len(cmds) == 0 || cmds[0] != "get" {
+		t.Fatalf("hooks saw %q, wanted the GETs and UPDATEs", cmds)
+	}
+}
+
+var _ = Describe("Counters", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(skytableOptions())
+		Expect(client.FlushDB(ctx, "").Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should Incr", func() {
+		n, err := client.Incr(ctx, "counter", 5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(5)))
+
+		n, err = client.Incr(ctx, "counter", 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(7)))
+	})
+
+	It("should Decr below zero", func() {
+		n, err := client.Decr(ctx, "counter", 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(-1)))
+
+		Expect(client.Incr(ctx, "counter", 3)).To(Equal(int64(2)))
+
+		n, err = client.Decr(ctx, "counter", 5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(-3)))
+
+		Expect(client.Get(ctx, "counter").Int64()).To(Equal(int64(-3)))
+	})
+
+	It("should IncrByFloat", func() {
+		var want float64
+		for i := 0; i < 10; i++ {
+			want += 0.1
+			f, err := client.IncrByFloat(ctx, "counter", 0.1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f).To(Equal(want))
+		}
+
+		Expect(client.Get(ctx, "counter").Float64()).To(Equal(want))
+	})
+
+	It("should not Incr a non-integer value", func() {
+		Expect(client.Set(ctx, "counter", "hello").Err()).NotTo(HaveOccurred())
+
+		_, err := client.Incr(ctx, "counter", 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("CompareAndSwap", func() {
+		It("should swap on match", func() {
+			Expect(client.Set(ctx, "key", "old").Err()).NotTo(HaveOccurred())
+
+			swapped, err := client.CompareAndSwap(ctx, "key", "old", "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeTrue())
+			Expect(client.Get(ctx, "key").Val()).To(Equal("new"))
+		})
+
+		It("should not swap on mismatch", func() {
+			Expect(client.Set(ctx, "key", "other").Err()).NotTo(HaveOccurred())
+
+			swapped, err := client.CompareAndSwap(ctx, "key", "old", "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+			Expect(client.Get(ctx, "key").Val()).To(Equal("other"))
+		})
+
+		It("should not swap a missing key", func() {
+			swapped, err := client.CompareAndSwap(ctx, "key", "old", "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+			Expect(client.Get(ctx, "key").Err()).To(Equal(skytable.Nil))
+		})
+
+		It("should create a missing key with KeyAbsent", func() {
+			swapped, err := client.CompareAndSwap(ctx, "key", skytable.KeyAbsent, "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeTrue())
+			Expect(client.Get(ctx, "key").Val()).To(Equal("new"))
+
+			swapped, err = client.CompareAndSwap(ctx, "key", skytable.KeyAbsent, "newer")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(swapped).To(BeFalse())
+			Expect(client.Get(ctx, "key").Val()).To(Equal("new"))
+		})
+	})
+})
@@ -0,0 +1,437 @@
+package skytable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrCascadeNotConfirmed is returned by DropKeyspaceCascade when it is
+// called without confirming the drop.
+var ErrCascadeNotConfirmed = errors.New("skytable: cascading drop not confirmed")
+
+// DropCascadeError is returned by DropKeyspaceCascade when the keyspace
+// could not be dropped.
+type DropCascadeError struct {
+	Keyspace string
+	// Tables maps the tables that could not be dropped to the reason,
+	// e.g. StillInUseError when clients are still connected to them.
+	Tables map[string]error
+	// Err is the error that stopped the keyspace from being dropped:
+	// the error of the first table that could not be dropped, or the error
+	// returned by DROP KEYSPACE.
+	Err error
+}
+
+func (e *DropCascadeError) Error() string {
+	if len(e.Tables) > 0 {
+		return fmt.Sprintf("skytable: can't drop keyspace %q: %d tables could not be dropped, first error: %v",
+			e.Keyspace, len(e.Tables), e.Err)
+	}
+	return fmt.Sprintf("skytable: can't drop keyspace %q: %v", e.Keyspace, e.Err)
+}
+
+func (e *DropCascadeError) Unwrap() error {
+	return e.Err
+}
+
+// DropKeyspaceCascade drops every table of keyspace, then the keyspace itself.
+// confirm must be true: it guards against dropping data by accident.
+//
+// Tables that can't be dropped don't stop the others from being dropped,
+// but they keep the keyspace from being dropped. Any failure is returned
+// as a *DropCascadeError; the tables dropped before it stay dropped.
+func (c *Client) DropKeyspaceCascade(ctx context.Context, keyspace string, confirm bool) error {
+	if !confirm {
+		return ErrCascadeNotConfirmed
+	}
+
+	tables, err := c.InspectKeyspace(ctx, keyspace).Result()
+	if err != nil {
+		return &DropCascadeError{Keyspace: keyspace, Err: err}
+	}
+
+	cascadeErr := &DropCascadeError{Keyspace: keyspace}
+	for _, table := range tables {
+		err := c.DropTable(ctx, keyspace+":"+table).Err()
+		if err == nil {
+			continue
+		}
+		if !isSkytableError(err) {
+			cascadeErr.Err = err
+			return cascadeErr
+		}
+		if cascadeErr.Tables == nil {
+			cascadeErr.Tables = make(map[string]error)
+			cascadeErr.Err = err
+		}
+		cascadeErr.Tables[table] = err
+	}
+	if cascadeErr.Err != nil {
+		return cascadeErr
+	}
+
+	if err := c.DropKeyspace(ctx, keyspace).Err(); err != nil {
+		cascadeErr.Err = err
+		return cascadeErr
+	}
+	return nil
+}
+
+// TableSpec describes a table created by Bootstrap or CreateTables.
+type TableSpec struct {
+	// Name of the table in the FQE syntax, e.g. "keyspace:table".
+	// A name without a keyspace is created in the default keyspace.
+	// CreateTables takes the name of the table within its keyspace.
+	Name string
+	// Model and ModelArgs are passed to CreateTable,
+	// e.g. "keymap" and []string{"str", "binstr"}.
+	Model      string
+	ModelArgs  []string
+	Properties []string
+}
+
+// BootstrapSpec lists the keyspaces and tables created by Bootstrap.
+type BootstrapSpec struct {
+	Keyspaces []string
+	Tables    []TableSpec
+}
+
+// BootstrapResult reports which entities Bootstrap created and which
+// already existed.
+type BootstrapResult struct {
+	Created []string
+	Existed []string
+}
+
+// Bootstrap creates the keyspaces of spec, then its tables, in the order
+// they are listed. Entities that already exist count as success, which
+// makes Bootstrap safe to run on every startup; their model is not checked.
+// Any other error stops Bootstrap and is returned with the result so far.
+func (c *Client) Bootstrap(ctx context.Context, spec BootstrapSpec) (*BootstrapResult, error) {
+	res := new(BootstrapResult)
+
+	track := func(entity string, err error) error {
+		switch err {
+		case nil:
+			res.Created = append(res.Created, entity)
+		case AlreadyExistsError:
+			res.Existed = append(res.Existed, entity)
+		default:
+			return fmt.Errorf("skytable: can't create %q: %w", entity, err)
+		}
+		return nil
+	}
+
+	for _, keyspace := range spec.Keyspaces {
+		err := c.CreateKeyspace(ctx, keyspace).Err()
+		if err := track(keyspace, err); err != nil {
+			return res, err
+		}
+	}
+	for _, table := range spec.Tables {
+		name := fullEntity(table.Name)
+		err := c.CreateTable(ctx, name, table.Model, table.ModelArgs, table.Properties...).Err()
+		if err := track(name, err); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// BootstrapTable makes sure that keyspace and its table exist, creating
+// them with model, modelArgs and properties if they don't, and makes the
+// table the current table of the client, as if it was set in
+// Options.Table. Pooled connections that had another table selected are
+// closed and redialed as needed, so call it at startup, before the client
+// is busy. Running it again with the same arguments is a no-op; the
+// model of a table that already exists is not checked.
+//
+// Leave Options.Table empty when it names the bootstrapped table:
+// connections fail to initialize while the table doesn't exist.
+func (c *Client) BootstrapTable(
+	ctx context.Context, keyspace, table, model string, modelArgs []string, properties ...string,
+) error {
+	entity := keyspace + ":" + table
+	if keyspace == "" || table == "" || strings.Count(entity, ":") != 1 {
+		return fmt.Errorf("skytable: invalid table %q", entity)
+	}
+	_, err := c.Bootstrap(ctx, BootstrapSpec{
+		Keyspaces: []string{keyspace},
+		Tables: []TableSpec{{
+			Name:       entity,
+			Model:      model,
+			ModelArgs:  modelArgs,
+			Properties: properties,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.table() != entity {
+		c.selected.Store(entity)
+		atomic.AddUint32(c.credsGen, 1)
+	}
+	return nil
+}
+
+// CreateTables creates the tables of specs in keyspace, whose names must
+// not include a keyspace. All the specs are checked before anything is
+// sent, and an invalid one fails the whole call.
+//
+// The CREATE TABLE commands are sent in one pipeline, or one by one if
+// the server doesn't support pipelining. The returned map holds the
+// outcome of every table by name: nil if it was created or already
+// existed, the error of the server otherwise. The error returned is set
+// only when the commands could not be sent, e.g. on a network failure.
+func (c *Client) CreateTables(ctx context.Context, keyspace string, specs []TableSpec) (map[string]error, error) {
+	if err := checkTableSpecs(keyspace, specs); err != nil {
+		return nil, err
+	}
+
+	create := func(cmdable Cmdable, spec TableSpec) *StatusCmd {
+		return cmdable.CreateTable(ctx, keyspace+":"+spec.Name, spec.Model, spec.ModelArgs, spec.Properties...)
+	}
+
+	pipe := c.Pipeline()
+	cmds := make([]*StatusCmd, len(specs))
+	for i, spec := range specs {
+		cmds[i] = create(pipe, spec)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !isSkytableError(err) {
+		return nil, err
+	}
+
+	res := make(map[string]error, len(specs))
+	for i, spec := range specs {
+		err := cmds[i].Err()
+		if err == ErrPipelineNotSupported {
+			err = create(c, spec).Err()
+			if err != nil && !isSkytableError(err) {
+				return res, err
+			}
+		}
+		if err == AlreadyExistsError {
+			err = nil
+		}
+		res[spec.Name] = err
+	}
+	return res, nil
+}
+
+func checkTableSpecs(keyspace string, specs []TableSpec) error {
+	if keyspace == "" || strings.Contains(keyspace, ":") {
+		return fmt.Errorf("skytable: invalid keyspace %q", keyspace)
+	}
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		switch {
+		case spec.Name == "" || strings.Contains(spec.Name, ":"):
+			return fmt.Errorf("skytable: invalid table name %q in keyspace %q", spec.Name, keyspace)
+		case seen[spec.Name]:
+			return fmt.Errorf("skytable: table %q listed twice", spec.Name)
+		case spec.Model == "":
+			return fmt.Errorf("skytable: table %q has no model", spec.Name)
+		}
+		if err := checkTableProperties(spec.Properties); err != nil {
+			return fmt.Errorf("skytable: table %q: %w", spec.Name, err)
+		}
+		seen[spec.Name] = true
+	}
+	return nil
+}
+
+// TableInfo describes a table, as parsed from the description returned
+// by INSPECT TABLE, e.g. "Keymap { data: (str,list<str>), volatile: false }".
+type TableInfo struct {
+	// Model is the data model, e.g. "Keymap".
+	Model string
+	// KeyType and ValueType are the types of the keys and values of a
+	// keymap table. ValueType is DataTypeList for a table of lists, and
+	// ElemType is then the type of the list elements.
+	KeyType   DataType
+	ValueType DataType
+	ElemType  DataType
+	Volatile  bool
+
+	// Raw is the description as returned by the server.
+	Raw string
+	// Extra holds the fields this client doesn't know about, e.g. ones
+	// added by newer servers, by name. It is nil if there are none.
+	Extra map[string]string
+}
+
+// IsList reports whether the table maps keys to lists.
+func (info *TableInfo) IsList() bool {
+	return info.ValueType == DataTypeList
+}
+
+// ParseTableInfo parses a table description returned by INSPECT TABLE.
+// Unknown fields don't make it fail: they are kept in TableInfo.Extra, so
+// descriptions from newer servers still parse.
+func ParseTableInfo(desc string) (*TableInfo, error) {
+	badDesc := fmt.Errorf("skytable: can't parse table description %q", desc)
+
+	i := strings.Index(desc, " {")
+	if i <= 0 || !strings.HasSuffix(desc, "}") {
+		return nil, badDesc
+	}
+	info := &TableInfo{Model: desc[:i], Raw: desc}
+
+	// The fields are "name: value" pairs separated by commas; the value of
+	// data is a parenthesized pair that contains a comma itself.
+	body := strings.TrimSpace(desc[i+2 : len(desc)-1])
+	for body != "" {
+		colon := strings.IndexByte(body, ':')
+		if colon < 0 {
+			return nil, badDesc
+		}
+		name := strings.TrimSpace(body[:colon])
+		body = strings.TrimSpace(body[colon+1:])
+
+		end := strings.IndexByte(body, ',')
+		if strings.HasPrefix(body, "(") {
+			end = strings.IndexByte(body, ')') + 1
+			if end == 0 {
+				return nil, badDesc
+			}
+		}
+		if end < 0 {
+			end = len(body)
+		}
+		val := strings.TrimSpace(body[:end])
+		body = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(body[end:]), ","))
+
+		switch name {
+		case "data":
+			types := strings.Split(strings.Trim(val, "()"), ",")
+			if len(types) != 2 {
+				return nil, badDesc
+			}
+			info.KeyType = DataType(strings.TrimSpace(types[0]))
+			info.ValueType = DataType(strings.TrimSpace(types[1]))
+			if elem := strings.TrimPrefix(string(info.ValueType), "list<"); elem != string(info.ValueType) {
+				info.ValueType = DataTypeList
+				info.ElemType = DataType(strings.TrimSuffix(elem, ">"))
+			}
+		case "volatile":
+			info.Volatile = val == "true"
+		default:
+			if info.Extra == nil {
+				info.Extra = make(map[string]string)
+			}
+			info.Extra[name] = val
+		}
+	}
+	return info, nil
+}
+
+// tableInfoCache keeps the TableInfo of tables by name. A table keeps its
+// model until it is dropped, so entries are only evicted by the commands
+// of the client that drop or create tables, see invalidate.
+type tableInfoCache struct {
+	mu     sync.Mutex
+	tables map[string]*TableInfo
+}
+
+func (c *tableInfoCache) get(table string) *TableInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tables[table]
+}
+
+func (c *tableInfoCache) set(table string, info *TableInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]*TableInfo)
+	}
+	c.tables[table] = info
+}
+
+// invalidate evicts the entries cmd may have made stale: the table it
+// dropped or created, or the tables of the keyspace it dropped. A command
+// failing with ErrWrongModel evicts every entry, as the table it ran
+// against may be cached under any name.
+func (c *tableInfoCache) invalidate(cmd Cmder) {
+	name := cmd.Name()
+	wrongModel := errors.Is(cmd.Err(), ErrWrongModel)
+	if name != "drop" && name != "create" && !wrongModel {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.tables) == 0 {
+		return
+	}
+	if wrongModel {
+		c.tables = nil
+		return
+	}
+
+	// CREATE <keyspace> has no third argument and evicts nothing.
+	entity := cmd.stringArg(2)
+	switch strings.ToLower(cmd.stringArg(1)) {
+	case "table":
+		// The cache may hold the table with or without its keyspace.
+		_, name := splitEntity(entity)
+		for table := range c.tables {
+			if _, t := splitEntity(table); t == name {
+				delete(c.tables, table)
+			}
+		}
+	case "keyspace":
+		for table := range c.tables {
+			if ks, _ := splitEntity(table); ks == "" || ks == entity {
+				delete(c.tables, table)
+			}
+		}
+	}
+}
+
+// splitEntity splits an entity into its keyspace, empty if it has none,
+// and its table.
+func splitEntity(entity string) (keyspace, table string) {
+	if i := strings.IndexByte(entity, ':'); i >= 0 {
+		return entity[:i], entity[i+1:]
+	}
+	return "", entity
+}
+
+// TableInfo returns the description of table, or of the current table of
+// the connection if table is empty. Named tables are inspected once and
+// cached until the client drops or creates the table or drops its
+// keyspace, or until a command of the client fails with ErrWrongModel.
+func (c *Client) TableInfo(ctx context.Context, table string) (*TableInfo, error) {
+	if table != "" {
+		if info := c.tables.get(table); info != nil {
+			return info, nil
+		}
+	}
+
+	args := []interface{}{"INSPECT", "TABLE"}
+	if table != "" {
+		args = append(args, table)
+	}
+	cmd := NewStringCmd(ctx, args...)
+	_ = c.Process(ctx, cmd)
+	desc, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParseTableInfo(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	if table != "" {
+		c.tables.set(table, info)
+	}
+	return info, nil
+}
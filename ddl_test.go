@@ -0,0 +1,473 @@
+package skytable_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestCreateListTable(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeStatus(0)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	err := rdb.CreateListTable(ctx, "ks:lists", skytable.DataTypeStr, skytable.DataTypeBinstr, "volatile").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.LSet(ctx, "list", "a", "b").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = rdb.CreateListTable(ctx, "ks:nested", skytable.DataTypeStr, skytable.DataTypeList).Err()
+	if err == nil {
+		t.Fatal("got nil, wanted an error for a nested list")
+	}
+
+	want := [][]string{
+		{"CREATE", "TABLE", "ks:lists", "keymap(str,list<binstr>)", "volatile"},
+		{"LSET", "list", "a", "b"},
+	}
+	if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestCreateTableProperties(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeStatus(0)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	for _, props := range [][]string{nil, {"volatile"}} {
+		if err := rdb.CreateTable(ctx, "ks:t", "keymap", []string{"str", "str"}, props...).Err(); err != nil {
+			t.Fatalf("%q: %v", props, err)
+		}
+	}
+
+	tests := []struct {
+		props []string
+		want  error
+	}{
+		{[]string{"volatile", "volatile"}, skytable.TooManyArgsError},
+		{[]string{"durable"}, skytable.UnknownPropertyError},
+		{[]string{"Volatile"}, skytable.UnknownPropertyError},
+	}
+	for _, tt := range tests {
+		err := rdb.CreateTable(ctx, "ks:t", "keymap", []string{"str", "str"}, tt.props...).Err()
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%q: got %v, wanted %v", tt.props, err, tt.want)
+		}
+	}
+	if n := len(srv.Commands()); n != 2 {
+		t.Fatalf("server got %d commands, wanted the 2 valid ones", n)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	existing := map[string]bool{"ks": true}
+	srv := newFakeServer(func(args []string) string {
+		entity := args[1]
+		if args[1] == "TABLE" {
+			entity = args[2]
+		}
+		switch {
+		case entity == "ks:bad":
+			return fakeError("unknown-model")
+		case existing[entity]:
+			return fakeError("err-already-exists")
+		default:
+			existing[entity] = true
+			return fakeStatus(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	spec := skytable.BootstrapSpec{
+		Keyspaces: []string{"ks"},
+		Tables: []skytable.TableSpec{
+			{Name: "ks:t1", Model: "keymap", ModelArgs: []string{"str", "str"}},
+			{Name: "t2", Model: "keymap", ModelArgs: []string{"str", "binstr"}},
+		},
+	}
+	res, err := rdb.Bootstrap(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Created, []string{"ks:t1", "default:t2"}) ||
+		!reflect.DeepEqual(res.Existed, []string{"ks"}) {
+		t.Fatalf("got %+v", res)
+	}
+
+	spec.Tables = append(spec.Tables, skytable.TableSpec{Name: "ks:bad", Model: "bad"})
+	res, err = rdb.Bootstrap(ctx, spec)
+	if err == nil {
+		t.Fatalf("got nil, wanted an error for ks:bad")
+	}
+	if len(res.Existed) != 3 {
+		t.Fatalf("got %+v, wanted the entities before ks:bad to exist", res)
+	}
+}
+
+func TestBootstrapTable(t *testing.T) {
+	existing := make(map[string]bool)
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "CREATE":
+			entity := args[len(args)-1]
+			if args[1] == "TABLE" {
+				entity = args[2]
+			}
+			if existing[entity] {
+				return fakeError("err-already-exists")
+			}
+			existing[entity] = true
+			return fakeStatus(0)
+		case "USE":
+			if !existing[args[1]] {
+				return fakeError("container-not-found")
+			}
+			return fakeStatus(0)
+		}
+		return fakeString("v")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	for i := 0; i < 2; i++ {
+		err := rdb.BootstrapTable(ctx, "ks", "t", "keymap", []string{"str", "str"}, "volatile")
+		if err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+		if err := rdb.Get(ctx, "a").Err(); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+
+	create := []string{"CREATE", "TABLE", "ks:t", "keymap(str,str)", "volatile"}
+	want := [][]string{
+		{"CREATE", "ks"},
+		create,
+		{"USE", "ks:t"},
+		{"GET", "a"},
+		{"CREATE", "ks"},
+		create,
+		{"GET", "a"},
+	}
+	if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	if n := srv.Dials(); n != 2 {
+		t.Fatalf("got %d dials, wanted 2: one to bootstrap, one with the table selected", n)
+	}
+
+	if err := rdb.BootstrapTable(ctx, "ks:x", "t", "keymap", nil); err == nil {
+		t.Fatal("got nil, wanted an error for a keyspace with a colon")
+	}
+}
+
+func TestCreateTables(t *testing.T) {
+	// reject is the number of commands answered as if pipelining was
+	// not supported, i.e. the ones of the pipeline sent first.
+	var reject int
+	existing := map[string]bool{"ks:t2": true}
+	srv := newFakeServer(func(args []string) string {
+		if reject > 0 {
+			reject--
+			return fakeError("pipeline-not-supported-yet")
+		}
+		switch table := args[2]; {
+		case table == "ks:bad":
+			return fakeError("unknown-model")
+		case existing[table]:
+			return fakeError("err-already-exists")
+		default:
+			existing[table] = true
+			return fakeStatus(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	specs := []skytable.TableSpec{
+		{Name: "t1", Model: "keymap", ModelArgs: []string{"str", "str"}},
+		{Name: "t2", Model: "keymap", ModelArgs: []string{"str", "binstr"}},
+		{Name: "t3", Model: "keymap", ModelArgs: []string{"str", "str"}, Properties: []string{"volatile"}},
+		{Name: "bad", Model: "bad"},
+	}
+	want := map[string]error{"t1": nil, "t2": nil, "t3": nil, "bad": skytable.UnknownModelError}
+
+	// The first round falls back to sending the commands one by one.
+	for _, pipelined := range []bool{false, true} {
+		if !pipelined {
+			reject = len(specs)
+		}
+		for table := range existing {
+			if table != "ks:t2" {
+				delete(existing, table)
+			}
+		}
+		res, err := rdb.CreateTables(ctx, "ks", specs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, want) {
+			t.Fatalf("pipelined=%v: got %v, wanted %v", pipelined, res, want)
+		}
+	}
+	if n := len(srv.Commands()); n != 3*len(specs) {
+		t.Fatalf("server got %d commands, wanted %d", n, 3*len(specs))
+	}
+
+	invalid := [][]skytable.TableSpec{
+		append(specs[:1:1], skytable.TableSpec{Name: "ks:t4", Model: "keymap"}),
+		append(specs[:1:1], skytable.TableSpec{Name: "t4"}),
+		append(specs[:1:1], specs[0]),
+		append(specs[:1:1], skytable.TableSpec{Name: "t4", Model: "keymap", Properties: []string{"durable"}}),
+	}
+	for _, specs := range invalid {
+		if _, err := rdb.CreateTables(ctx, "ks", specs); err == nil {
+			t.Fatalf("%+v: got nil, wanted an error", specs)
+		}
+	}
+	if n := len(srv.Commands()); n != 3*len(specs) {
+		t.Fatalf("server got %d commands, wanted none sent for invalid specs", n)
+	}
+}
+
+func TestDropKeyspaceCascadeStillInUse(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch {
+		case args[0] == "INSPECT":
+			return fakeArray(fakeString("t1"), fakeString("t2"), fakeString("t3"))
+		case args[2] == "ks:t2":
+			return fakeError("still-in-use")
+		default:
+			return fakeStatus(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.DropKeyspaceCascade(ctx, "ks", false); err != skytable.ErrCascadeNotConfirmed {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrCascadeNotConfirmed)
+	}
+	if n := len(srv.Commands()); n != 0 {
+		t.Fatalf("got %d commands without confirmation, wanted none", n)
+	}
+
+	err := rdb.DropKeyspaceCascade(ctx, "ks", true)
+	var cascadeErr *skytable.DropCascadeError
+	if !errors.As(err, &cascadeErr) {
+		t.Fatalf("got %v, wanted a *DropCascadeError", err)
+	}
+	if !errors.Is(err, skytable.StillInUseError) {
+		t.Fatalf("got %v, wanted it to wrap %v", err, skytable.StillInUseError)
+	}
+	if len(cascadeErr.Tables) != 1 || cascadeErr.Tables["t2"] != skytable.StillInUseError {
+		t.Fatalf("got %v, wanted only t2 to fail", cascadeErr.Tables)
+	}
+
+	var dropped []string
+	for _, args := range srv.Commands() {
+		if args[0] == "DROP" {
+			dropped = append(dropped, args[1]+" "+args[2])
+		}
+	}
+	want := []string{"TABLE ks:t1", "TABLE ks:t2", "TABLE ks:t3"}
+	if len(dropped) != len(want) {
+		t.Fatalf("got %q, wanted %q", dropped, want)
+	}
+	for i := range want {
+		if dropped[i] != want[i] {
+			t.Fatalf("got %q, wanted %q", dropped, want)
+		}
+	}
+}
+
+var _ = Describe("DropKeyspaceCascade", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(skytableOptions())
+		_ = client.DropKeyspaceCascade(ctx, "cascade", true)
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should drop a keyspace with tables", func() {
+		Expect(client.CreateKeyspace(ctx, "cascade").Err()).NotTo(HaveOccurred())
+		for _, table := range []string{"cascade:t1", "cascade:t2"} {
+			err := client.CreateTable(ctx, table, "keymap", []string{"str", "str"}).Err()
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(client.DropKeyspace(ctx, "cascade").Err()).To(HaveOccurred())
+
+		Expect(client.DropKeyspaceCascade(ctx, "cascade", false)).To(Equal(skytable.ErrCascadeNotConfirmed))
+		Expect(client.DropKeyspaceCascade(ctx, "cascade", true)).NotTo(HaveOccurred())
+		Expect(client.InspectKeyspaces(ctx).Val()).NotTo(ContainElement("cascade"))
+	})
+})
+
+var _ = Describe("Bootstrap", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(skytableOptions())
+		_ = client.DropKeyspaceCascade(ctx, "bootstrap", true)
+	})
+
+	AfterEach(func() {
+		Expect(client.DropKeyspaceCascade(ctx, "bootstrap", true)).NotTo(HaveOccurred())
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should bootstrap idempotently", func() {
+		spec := skytable.BootstrapSpec{
+			Keyspaces: []string{"bootstrap"},
+			Tables: []skytable.TableSpec{
+				{Name: "bootstrap:users", Model: "keymap", ModelArgs: []string{"str", "str"}},
+				{Name: "bootstrap:blobs", Model: "keymap", ModelArgs: []string{"str", "binstr"}},
+			},
+		}
+		all := []string{"bootstrap", "bootstrap:users", "bootstrap:blobs"}
+
+		res, err := client.Bootstrap(ctx, spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Created).To(Equal(all))
+		Expect(res.Existed).To(BeEmpty())
+
+		res, err = client.Bootstrap(ctx, spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Created).To(BeEmpty())
+		Expect(res.Existed).To(Equal(all))
+
+		Expect(client.InspectKeyspace(ctx, "bootstrap").Val()).To(ConsistOf("users", "blobs"))
+	})
+})
+
+func TestParseTableInfo(t *testing.T) {
+	tests := []struct {
+		desc string
+		want skytable.TableInfo
+	}{
+		{
+			"Keymap { data: (binstr,binstr), volatile: true }",
+			skytable.TableInfo{Model: "Keymap", KeyType: "binstr", ValueType: "binstr", Volatile: true},
+		},
+		{
+			"Keymap { data: (str,list<str>), volatile: false }",
+			skytable.TableInfo{Model: "Keymap", KeyType: "str", ValueType: skytable.DataTypeList, ElemType: "str"},
+		},
+		{
+			// A hypothetical newer format with extra fields.
+			"Keymap { data: (str,str), volatile: false, replicas: 3, shard: (a,b) }",
+			skytable.TableInfo{
+				Model: "Keymap", KeyType: "str", ValueType: "str",
+				Extra: map[string]string{"replicas": "3", "shard": "(a,b)"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := skytable.ParseTableInfo(tt.desc)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.desc, err)
+		}
+		tt.want.Raw = tt.desc
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Fatalf("%q: got %+v, wanted %+v", tt.desc, *got, tt.want)
+		}
+	}
+
+	for _, desc := range []string{"", "Keymap", "Keymap { data: (str) }", "Keymap { data: (str,str }"} {
+		if _, err := skytable.ParseTableInfo(desc); err == nil {
+			t.Errorf("%q: got nil error", desc)
+		}
+	}
+}
+
+func TestTableInfoDropAndRecreate(t *testing.T) {
+	desc := "Keymap { data: (str,str), volatile: false }"
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "INSPECT":
+			return fakeString(desc)
+		case "CREATE":
+			desc = "Keymap { data: (str,list<str>), volatile: false }"
+			return fakeStatus(0)
+		case "GET":
+			return fakeError("wrong-model")
+		default:
+			return fakeStatus(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	inspect := func() *skytable.TableInfo {
+		t.Helper()
+		info, err := rdb.TableInfo(ctx, "ks:t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+
+	if info := inspect(); info.ValueType != "str" {
+		t.Fatalf("got %+v, wanted str values", info)
+	}
+	if err := rdb.DropTable(ctx, "ks:t").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.CreateTable(ctx, "ks:t", "keymap", []string{"str", "list<str>"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if info := inspect(); info.ValueType != skytable.DataTypeList {
+		t.Fatalf("got %+v after recreating the table, wanted list values", info)
+	}
+
+	inspects := func() int {
+		var n int
+		for _, args := range srv.Commands() {
+			if args[0] == "INSPECT" {
+				n++
+			}
+		}
+		return n
+	}
+	// Cached until a command fails with ErrWrongModel, or the keyspace
+	// is dropped.
+	inspect()
+	if n := inspects(); n != 2 {
+		t.Fatalf("got %d INSPECTs, wanted 2", n)
+	}
+	if err := rdb.Get(ctx, "key").Err(); err != skytable.ErrWrongModel {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrWrongModel)
+	}
+	inspect()
+	if err := rdb.DropKeyspace(ctx, "ks").Err(); err != nil {
+		t.Fatal(err)
+	}
+	inspect()
+	if n := inspects(); n != 4 {
+		t.Fatalf("got %d INSPECTs, wanted 4", n)
+	}
+}
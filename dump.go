@@ -0,0 +1,162 @@
+package skytable
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// dumpPipelineBatches is the number of MGETs of migrateBatchSize keys
+// Dump sends in one pipeline.
+const dumpPipelineBatches = 10
+
+// Dump writes every key of the current table and its value to w and
+// returns the number of keys written. Load reads them back.
+//
+// The export is a sequence of entries, one per key. An entry is the key
+// followed by the value, each written as its length in bytes in decimal,
+// a newline, the bytes themselves and another newline, e.g. "1\na\n2\nv1\n"
+// for the key "a" holding "v1". Keys and values can hold any byte.
+//
+// Keys are listed with LSKEYS and read with pipelined MGETs; the dump is
+// not a snapshot, so keys written while it runs may or may not be in it
+// and keys deleted meanwhile are left out.
+// With Options.EmulateExpiry, the keys SetEx uses to emulate expiry are
+// left out too.
+func (c *Client) Dump(ctx context.Context, w io.Writer) (int64, error) {
+	// The raw size, as LSKEYS counts the expiry keys it leaves out.
+	n, err := c.cmdable.DbSize(ctx, "").Result()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	keys, err := c.LSKeys(ctx, "", int(n)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	var dumped int64
+	for len(keys) > 0 {
+		pipe := c.Pipeline()
+		var batches [][]string
+		for i := 0; i < dumpPipelineBatches && len(keys) > 0; i++ {
+			n := migrateBatchSize
+			if n > len(keys) {
+				n = len(keys)
+			}
+			pipe.MGet(ctx, keys[:n])
+			batches = append(batches, keys[:n])
+			keys = keys[n:]
+		}
+
+		cmds, err := pipe.Exec(ctx)
+		if err != nil {
+			return dumped, err
+		}
+		for i, cmd := range cmds {
+			for j, val := range cmd.(*SliceCmd).Val() {
+				key := batches[i][j]
+				switch val := val.(type) {
+				case nil:
+					// Deleted since the keys were listed.
+					continue
+				case string:
+					writeDumpEntry(bw, key, val)
+				case []byte:
+					writeDumpEntry(bw, key, string(val))
+				case error:
+					return dumped, fmt.Errorf("skytable: can't dump key %q: %w", key, val)
+				default:
+					return dumped, fmt.Errorf("skytable: can't dump key %q holding %T", key, val)
+				}
+				dumped++
+			}
+		}
+	}
+	return dumped, bw.Flush()
+}
+
+func writeDumpEntry(bw *bufio.Writer, key, val string) {
+	for _, s := range [2]string{key, val} {
+		bw.WriteString(strconv.Itoa(len(s)))
+		bw.WriteByte('\n')
+		bw.WriteString(s)
+		bw.WriteByte('\n')
+	}
+}
+
+// Load reads an export written by Dump from r and sets its keys in the
+// current table with USET, overwriting the keys that already exist. It
+// returns the number of keys set, which are sent in batches: if Load
+// fails, the keys of the batches sent before are set.
+func (c *Client) Load(ctx context.Context, r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var loaded int64
+	pairs := make([]interface{}, 0, 2*migrateBatchSize)
+	flush := func() error {
+		if len(pairs) == 0 {
+			return nil
+		}
+		err := c.USet(ctx, pairs...).Err()
+		if err == nil {
+			loaded += int64(len(pairs) / 2)
+		}
+		pairs = pairs[:0]
+		return err
+	}
+
+	for {
+		key, err := readDumpField(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+		val, err := readDumpField(br)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		pairs = append(pairs, key, val)
+		if len(pairs) == cap(pairs) {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	return loaded, flush()
+}
+
+// readDumpField reads one length-prefixed field of a Dump export. It
+// returns io.EOF only at the end of the input.
+func readDumpField(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	n, err := strconv.Atoi(line[:len(line)-1])
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("skytable: invalid dump field length %q", line)
+	}
+
+	b := make([]byte, n+1)
+	if _, err := io.ReadFull(br, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	if b[n] != '\n' {
+		return "", fmt.Errorf("skytable: dump field of %d bytes not followed by a newline", n)
+	}
+	return string(b[:n]), nil
+}
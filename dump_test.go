@@ -0,0 +1,99 @@
+package skytable_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestDumpLoad(t *testing.T) {
+	src := newFakeKeymap()
+	srcSrv := newFakeServer(src.handle)
+	srcClient := skytable.NewClient(srcSrv.options())
+	defer srcClient.Close()
+
+	want := map[string]string{
+		"empty":   "",
+		"newline": "a\nb\n",
+		"binary":  "\x00\xff",
+	}
+	// Enough keys for several MGETs.
+	for i := 0; i < 250; i++ {
+		want[fmt.Sprintf("key%d", i)] = fmt.Sprint(i)
+	}
+	pairs := make(map[string]interface{}, len(want))
+	for k, v := range want {
+		pairs[k] = v
+	}
+	if err := srcClient.BulkWrite(ctx, skytable.Upsert, pairs).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := srcClient.Dump(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(want)) {
+		t.Fatalf("dumped %d keys, wanted %d", n, len(want))
+	}
+	dump := buf.String()
+
+	dst := newFakeKeymap()
+	dstSrv := newFakeServer(dst.handle)
+	dstClient := skytable.NewClient(dstSrv.options())
+	defer dstClient.Close()
+
+	n, err = dstClient.Load(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(want)) {
+		t.Fatalf("loaded %d keys, wanted %d", n, len(want))
+	}
+	if !reflect.DeepEqual(dst.m, want) {
+		t.Fatalf("got %d keys after Load, wanted %d", len(dst.m), len(want))
+	}
+
+	for _, bad := range []string{dump[:len(dump)-1], "1\na\n", "x\n", "3\nabcd\n1\n1\n"} {
+		_, err := dstClient.Load(ctx, bytes.NewBufferString(bad))
+		if err == nil || err == io.EOF {
+			t.Errorf("%.20q: got %v, wanted an error", bad, err)
+		}
+	}
+}
+
+func TestDumpSkipsExpiryKeys(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "plain", "v1").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.SetEx(ctx, "expiring", "v2", time.Hour).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := rdb.LSKeys(ctx, "", 10).Result()
+	if err != nil || !reflect.DeepEqual(keys, []string{"expiring", "plain"}) {
+		t.Fatalf("LSKeys: got %q, %v", keys, err)
+	}
+	if n, err := rdb.DbSize(ctx, "").Result(); err != nil || n != 2 {
+		t.Fatalf("DbSize: got %d, %v, wanted 2", n, err)
+	}
+
+	var buf bytes.Buffer
+	if n, err := rdb.Dump(ctx, &buf); err != nil || n != 2 {
+		t.Fatalf("Dump: got %d, %v, wanted 2", n, err)
+	}
+	if want := "8\nexpiring\n2\nv2\n5\nplain\n2\nv1\n"; buf.String() != want {
+		t.Fatalf("got %q, wanted %q", buf.String(), want)
+	}
+}
@@ -2,9 +2,12 @@ package skytable
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"strings"
+	"syscall"
 
 	"github.com/satvik007/skytable-go/internal/pool"
 	"github.com/satvik007/skytable-go/internal/proto"
@@ -13,6 +16,116 @@ import (
 // ErrClosed performs any operation on the closed client will return this error.
 var ErrClosed = pool.ErrClosed
 
+// ErrConnBroken is matched by errors.Is for the commands run on a Conn
+// after its connection broke. The Conn doesn't dial a new connection, as it
+// wouldn't have the keyspace or table selected with USE; Close the Conn
+// and use a new one.
+var ErrConnBroken = pool.ErrBadConn
+
+// ErrConnInUse is returned for a command run on a Conn while another
+// command or pipeline runs on it, e.g. from another goroutine. The
+// command is not sent, so the replies of the other one can't get mixed
+// up with its own.
+var ErrConnInUse = errors.New("skytable: Conn in use by another command")
+
+// ErrReplyCountMismatch is returned when a reply holds a different number
+// of elements than the command asked for, e.g. MGET values than keys.
+var ErrReplyCountMismatch = errors.New("skytable: reply count mismatch")
+
+// ErrReadOnly is returned for commands that change data when the client
+// was created with Options.ReadOnly. They are never sent to the server.
+var ErrReadOnly = errors.New("skytable: write command rejected by read-only client")
+
+// ErrCommandNotAllowed is returned for commands rejected by
+// Options.AllowedCommands or Options.DeniedCommands. They are never sent
+// to the server.
+var ErrCommandNotAllowed = errors.New("skytable: command not allowed")
+
+// ErrEchoMismatch is returned by VerifyEcho when the server echoes a payload
+// other than the one sent, which means the connection is out of sync.
+var ErrEchoMismatch = errors.New("skytable: echo mismatch")
+
+// ErrValueMismatch is returned by VerifySet when the value read back
+// differs from the one written.
+var ErrValueMismatch = errors.New("skytable: value mismatch")
+
+// ErrExpiryNotEnabled is returned by SetEx and GetEx when
+// Options.EmulateExpiry is not set.
+var ErrExpiryNotEnabled = errors.New("skytable: expiry emulation not enabled")
+
+// errTableSwitched is the reason a Conn's connection is closed instead of
+// being returned to the pool after USE.
+var errTableSwitched = errors.New("skytable: Conn switched tables")
+
+// ErrInvalidInterval is sent by WatchDbSize when the polling interval is
+// not positive.
+var ErrInvalidInterval = errors.New("skytable: invalid interval")
+
+// ErrUnsupportedProtocol is matched by errors.Is when
+// Options.VerifyProtocol is set and the server reports a Skyhash major
+// version the client doesn't speak. The connection is closed before any
+// command is sent on it.
+var ErrUnsupportedProtocol = errors.New("skytable: unsupported protocol version")
+
+// The Skyhash major versions the client speaks, as reported by
+// SYS INFO protover.
+const (
+	minProtocolMajor = 1
+	maxProtocolMajor = 1
+)
+
+type unsupportedProtocolError struct {
+	version float64
+}
+
+func (e unsupportedProtocolError) Error() string {
+	return fmt.Sprintf("%s: server speaks %g, client speaks %d.x",
+		ErrUnsupportedProtocol, e.version, maxProtocolMajor)
+}
+
+func (e unsupportedProtocolError) Is(target error) bool {
+	return target == ErrUnsupportedProtocol
+}
+
+// ErrServerUnavailable is matched by errors.Is when a command failed on
+// every attempt because the server closed, reset or refused the
+// connection, e.g. while it restarts. errors.Unwrap gives the last network
+// error.
+var ErrServerUnavailable = errors.New("skytable: server unavailable")
+
+type serverUnavailableError struct {
+	err error
+}
+
+func (e serverUnavailableError) Error() string {
+	return ErrServerUnavailable.Error() + ": " + e.err.Error()
+}
+
+func (e serverUnavailableError) Unwrap() error {
+	return e.err
+}
+
+func (e serverUnavailableError) Is(target error) bool {
+	return target == ErrServerUnavailable
+}
+
+// ProtocolError is returned when a reply doesn't follow the Skyhash
+// protocol, e.g. because the stream is corrupt.
+type ProtocolError = proto.ProtocolError
+
+// ErrReplyTypeMismatch is matched by errors.Is when a reply has another
+// type than the command reads; errors.As with *ReplyTypeMismatchError
+// gives the expected and actual type bytes.
+var ErrReplyTypeMismatch = proto.ErrReplyTypeMismatch
+
+type ReplyTypeMismatchError = proto.ReplyTypeMismatchError
+
+// ErrReplyTooLarge is matched by errors.Is when a reply announces more
+// bytes or elements than Options.MaxReplySize. The connection is closed.
+var ErrReplyTooLarge = proto.ErrReplyTooLarge
+
+type ReplyTooLargeError = proto.ReplyTooLargeError
+
 type Error interface {
 	error
 
@@ -74,6 +187,21 @@ func shouldRetry(err error, retryTimeout bool) bool {
 	return false
 }
 
+// serverUnavailable wraps err, the error left once retries are exhausted,
+// with ErrServerUnavailable if it means the server has gone away.
+func serverUnavailable(err error) error {
+	switch {
+	case errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return serverUnavailableError{err: err}
+	default:
+		return err
+	}
+}
+
 func isSkytableError(err error) bool {
 	_, ok := err.(proto.SkytableError)
 	return ok
@@ -124,6 +252,10 @@ func isMovedSameConnAddr(err error, addr string) bool {
 	return strings.HasSuffix(skytableError, " "+addr)
 }
 
+func replyCountMismatch(got, want int) error {
+	return fmt.Errorf("%w: got %d elements, wanted %d", ErrReplyCountMismatch, got, want)
+}
+
 // ------------------------------------------------------------------------------
 
 type timeoutError interface {
@@ -102,7 +102,7 @@ var _ = Describe("example_test", func() {
 // }
 
 // func ExampleClient_SetEx() {
-//   err := rdb.SetEx(ctx, "key", "value").Err()
+//   err := rdb.SetEx(ctx, "key", "value", time.Hour).Err()
 //   if err != nil {
 //     panic(err)
 //   }
@@ -0,0 +1,356 @@
+package skytable
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/satvik007/skytable-go/internal/proto"
+)
+
+// Skytable keymap tables have no TTL, so expiry is emulated on the client
+// when Options.EmulateExpiry is set. SetEx stores the deadline in a
+// companion key and the Client deletes both keys once it has passed.
+//
+// The companion key also holds a checksum of the value SetEx wrote, so
+// that a key deleted and written again without SetEx, which leaves the
+// companion key behind, is not deleted in its place.
+
+// expirySuffix is appended to a key to build the key holding its deadline.
+// It is also, on its own, the key marking a table where SetEx was used.
+const expirySuffix = "\x00skytable:expiry"
+
+func expiryKey(key string) string {
+	return key + expirySuffix
+}
+
+func isExpiryKey(key string) bool {
+	return strings.HasSuffix(key, expirySuffix)
+}
+
+// expiryValue returns the content of the companion key of a key holding
+// val, that expires at deadline: "<deadline>:<checksum of val>".
+func expiryValue(deadline time.Time, val []byte) string {
+	return strconv.FormatInt(deadline.UnixNano(), 10) + ":" + valueSum(val)
+}
+
+// parseExpiry parses the content of a companion key. sum is empty for
+// deadlines stored without a checksum.
+func parseExpiry(s string) (deadline time.Time, sum string, err error) {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		s, sum = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return time.Unix(0, n), sum, nil
+}
+
+// ownsValue reports whether val is still the value the companion key
+// with checksum sum was written for.
+func ownsValue(sum string, val []byte) bool {
+	return sum == "" || sum == valueSum(val)
+}
+
+func valueSum(val []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(val)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// argBytes returns v as the server stores it when it is sent as an argument.
+func argBytes(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := proto.NewWriter(&buf).WriteArg(v); err != nil {
+		return nil, err
+	}
+	b := buf.Bytes()
+	// Strip the length line and the trailing newline.
+	return b[bytes.IndexByte(b, '\n')+1 : len(b)-1], nil
+}
+
+// expiryReaper runs the reaper of each key at its deadline. The deadlines
+// are kept in a heap served by a single timer, however many keys expire.
+type expiryReaper struct {
+	mu      sync.Mutex
+	entries map[string]*expiryEntry
+	queue   expiryQueue
+	timer   *time.Timer
+	closed  bool
+}
+
+type expiryEntry struct {
+	key   string
+	at    time.Time
+	fn    func()
+	index int
+}
+
+// expiryQueue is a heap of entries, earliest deadline first.
+type expiryQueue []*expiryEntry
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiryQueue) Push(x interface{}) {
+	e := x.(*expiryEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
+
+func newExpiryReaper() *expiryReaper {
+	return &expiryReaper{
+		entries: make(map[string]*expiryEntry),
+	}
+}
+
+// schedule runs fn for key after d, replacing any fn already pending for key.
+func (r *expiryReaper) schedule(key string, d time.Duration, fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return
+	}
+	at := time.Now().Add(d)
+	if e, ok := r.entries[key]; ok {
+		e.at, e.fn = at, fn
+		heap.Fix(&r.queue, e.index)
+	} else {
+		e := &expiryEntry{key: key, at: at, fn: fn}
+		heap.Push(&r.queue, e)
+		r.entries[key] = e
+	}
+	r.resetTimer()
+}
+
+// resetTimer makes the timer fire at the earliest deadline. r.mu is held.
+func (r *expiryReaper) resetTimer() {
+	if len(r.queue) == 0 {
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		return
+	}
+	d := time.Until(r.queue[0].at)
+	if r.timer == nil {
+		r.timer = time.AfterFunc(d, r.run)
+	} else {
+		r.timer.Reset(d)
+	}
+}
+
+// run runs the reapers whose deadline has passed, one after the other.
+func (r *expiryReaper) run() {
+	r.mu.Lock()
+	var due []*expiryEntry
+	now := time.Now()
+	for len(r.queue) > 0 && !r.queue[0].at.After(now) {
+		e := heap.Pop(&r.queue).(*expiryEntry)
+		delete(r.entries, e.key)
+		due = append(due, e)
+	}
+	if !r.closed {
+		r.resetTimer()
+	}
+	r.mu.Unlock()
+
+	for _, e := range due {
+		e.fn()
+	}
+}
+
+func (r *expiryReaper) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.queue = nil
+	r.entries = nil
+	return nil
+}
+
+// SetEx sets key to value and makes it expire after ttl.
+// Unlike Set, an existing key is overwritten.
+//
+// SetEx requires Options.EmulateExpiry, and fails with ErrExpiryNotEnabled
+// without it. Expiry is emulated on the client and is approximate: the
+// deadline is kept in a companion key and the key is deleted by this Client,
+// some time after ttl has elapsed. Until then other clients still see the key,
+// and if this Client is closed before the deadline, the key never expires.
+// Use GetEx to read the key with the deadline enforced.
+//
+// The companion key is named after key, followed by a NUL byte and
+// "skytable:expiry", and SetEx also writes a key made of that suffix alone
+// to mark the table. With the option, LSKEYS, DbSize, Dump and MigrateTable
+// leave these keys out. Deleting key with Del leaves its companion key behind until the
+// deadline, when it is deleted without touching a value written to key
+// since, unless that value is the very one SetEx wrote. The marker key is
+// never deleted.
+func (c *Client) SetEx(ctx context.Context, key string, value interface{}, ttl time.Duration) *StatusCmd {
+	if !c.opt.EmulateExpiry {
+		cmd := NewStatusCmd(ctx, "USET", key, value)
+		cmd.SetErr(ErrExpiryNotEnabled)
+		return cmd
+	}
+	deadline := time.Now().Add(ttl)
+	b, err := argBytes(value)
+	if err != nil {
+		cmd := NewStatusCmd(ctx, "USET", key, value)
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd := NewStatusCmd(ctx, "USET", key, value,
+		expiryKey(key), expiryValue(deadline, b), expirySuffix, "1")
+
+	unlock := c.keyLocks.lock(key)
+	uset := NewIntCmd(ctx, cmd.Args()...)
+	_ = c.Process(ctx, uset)
+	unlock()
+
+	cmd.SetErr(uset.Err())
+	if uset.Err() == nil {
+		c.scheduleExpiry(key, ttl)
+	}
+	return cmd
+}
+
+// GetEx returns the value of key and resets its expiry to ttl from now,
+// which keeps frequently read keys alive. A key without an expiry gets one.
+// A key whose deadline has passed is deleted and Nil is returned, even if
+// the reaper has not run yet. See SetEx for the limits of client-side expiry.
+// Like SetEx, GetEx requires Options.EmulateExpiry.
+func (c *Client) GetEx(ctx context.Context, key string, ttl time.Duration) *StringCmd {
+	cmd := NewStringCmd(ctx, "GET", key)
+	if !c.opt.EmulateExpiry {
+		cmd.SetErr(ErrExpiryNotEnabled)
+		return cmd
+	}
+
+	defer c.keyLocks.lock(key)()
+
+	if err := c.Process(ctx, cmd); err != nil {
+		return cmd
+	}
+	val, _ := cmd.Bytes()
+
+	exp, err := c.Get(ctx, expiryKey(key)).Result()
+	switch err {
+	case nil:
+		// A deadline left behind by a value since replaced is not enforced.
+		deadline, sum, err := parseExpiry(exp)
+		if err == nil && ownsValue(sum, val) && !time.Now().Before(deadline) {
+			_ = c.Process(ctx, NewIntCmd(ctx, "DEL", key, expiryKey(key)))
+			cmd.SetVal("")
+			cmd.SetErr(Nil)
+			return cmd
+		}
+	case Nil:
+	default:
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	deadline := time.Now().Add(ttl)
+	uset := NewIntCmd(ctx, "USET", expiryKey(key), expiryValue(deadline, val), expirySuffix, "1")
+	if err := c.Process(ctx, uset); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	c.scheduleExpiry(key, ttl)
+	return cmd
+}
+
+// DbSize returns the number of keys in entity, or in the current table if
+// entity is empty. With Options.EmulateExpiry, the keys SetEx uses to
+// emulate expiry are left out of the count of the current table: that
+// costs an EXISTS and, if SetEx was used on the table, listing all its keys.
+func (c *Client) DbSize(ctx context.Context, entity string) *IntCmd {
+	cmd := c.cmdable.DbSize(ctx, entity)
+	if !c.opt.EmulateExpiry ||
+		cmd.Err() != nil || cmd.Val() == 0 || entity != "" && entity != c.table() {
+		return cmd
+	}
+
+	n, err := c.Exists(ctx, expirySuffix).Result()
+	if err != nil || n == 0 {
+		cmd.SetErr(err)
+		return cmd
+	}
+	// LSKEYS leaves the expiry keys out of the listing of all the keys.
+	keys, err := c.LSKeys(ctx, "", int(cmd.Val())).Result()
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd.SetVal(int64(len(keys)))
+	return cmd
+}
+
+func (c *Client) scheduleExpiry(key string, ttl time.Duration) {
+	// The reaper runs long after the call that scheduled it, so it must
+	// not obey the context of a clone made with WithContext.
+	reaper := c
+	if c.ctx.Done() != nil {
+		reaper = c.WithContext(context.Background())
+	}
+	c.expiry.schedule(key, ttl, func() {
+		reaper.reapExpired(key)
+	})
+}
+
+// reapExpired deletes key if its deadline has passed. A deadline that was
+// moved forward in the meantime is rescheduled. If key was deleted or holds
+// another value than the one the deadline was set for, only the companion
+// key is deleted.
+func (c *Client) reapExpired(key string) {
+	defer c.keyLocks.lock(key)()
+
+	ctx := context.Background()
+	exp, err := c.Get(ctx, expiryKey(key)).Result()
+	if err != nil {
+		// The key was deleted or the server could not be reached.
+		return
+	}
+	deadline, sum, err := parseExpiry(exp)
+	if err != nil {
+		return
+	}
+
+	if left := time.Until(deadline); left > 0 {
+		c.scheduleExpiry(key, left)
+		return
+	}
+
+	get := c.Get(ctx, key)
+	val, err := get.Bytes()
+	switch {
+	case err == Nil || err == nil && !ownsValue(sum, val):
+		_ = c.Process(ctx, NewIntCmd(ctx, "DEL", expiryKey(key)))
+	case err == nil:
+		_ = c.Process(ctx, NewIntCmd(ctx, "DEL", key, expiryKey(key)))
+	}
+}
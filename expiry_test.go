@@ -0,0 +1,258 @@
+package skytable_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func withExpiry(opt *skytable.Options) *skytable.Options {
+	opt.EmulateExpiry = true
+	return opt
+}
+
+func TestSetExNotEnabled(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.SetEx(ctx, "key", "value", time.Hour).Err(); err != skytable.ErrExpiryNotEnabled {
+		t.Fatalf("SetEx: got %v, wanted %v", err, skytable.ErrExpiryNotEnabled)
+	}
+	if err := rdb.GetEx(ctx, "key", time.Hour).Err(); err != skytable.ErrExpiryNotEnabled {
+		t.Fatalf("GetEx: got %v, wanted %v", err, skytable.ErrExpiryNotEnabled)
+	}
+	if n := len(srv.Commands()); n != 0 {
+		t.Fatalf("got %d commands, wanted none", n)
+	}
+
+	// Without the option, DBSIZE is sent alone and LSKEYS is left as is.
+	kv.handle([]string{"USET", "key\x00skytable:expiry", "1"})
+	if n, err := rdb.DbSize(ctx, "").Result(); err != nil || n != 1 {
+		t.Fatalf("DbSize: got %d, %v, wanted 1", n, err)
+	}
+	if keys := rdb.LSKeys(ctx, "", 0).Val(); len(keys) != 1 {
+		t.Fatalf("LSKeys: got %q, wanted the expiry key", keys)
+	}
+	if n := len(srv.Commands()); n != 2 {
+		t.Fatalf("got %d commands, wanted 2", n)
+	}
+}
+
+func TestSetExManyKeys(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	// Deadlines are scheduled out of order and one is moved.
+	for i, ttl := range []time.Duration{90, 30, 60, 10} {
+		key := string(rune('a' + i))
+		if err := rdb.SetEx(ctx, key, "value", ttl*time.Millisecond).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rdb.SetEx(ctx, "d", "value", 20*time.Millisecond).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		for {
+			if _, ok := kv.Get(key); !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s did not expire", key)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestSetExExpires(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	if err := rdb.SetEx(ctx, "key", "value", 50*time.Millisecond).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := rdb.Get(ctx, "key").Result(); err != nil || val != "value" {
+		t.Fatalf("got %q, %v, wanted %q", val, err, "value")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for rdb.Get(ctx, "key").Err() != skytable.Nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("key did not expire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := kv.Get("key\x00skytable:expiry"); ok {
+		t.Fatalf("expiry key was not deleted")
+	}
+}
+
+func TestGetExKeepsKeyAlive(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	const ttl = 150 * time.Millisecond
+	if err := rdb.SetEx(ctx, "key", "value", ttl).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		time.Sleep(ttl / 5)
+		val, err := rdb.GetEx(ctx, "key", ttl).Result()
+		if err != nil {
+			t.Fatalf("GetEx #%d: %v", i, err)
+		}
+		if val != "value" {
+			t.Fatalf("got %q, wanted %q", val, "value")
+		}
+	}
+
+	time.Sleep(2 * ttl)
+	if err := rdb.GetEx(ctx, "key", ttl).Err(); err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+}
+
+func TestSetExDelThenSet(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	const ttl = 50 * time.Millisecond
+	if err := rdb.SetEx(ctx, "key", "old", ttl).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Del(ctx, "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Set(ctx, "key", "new").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The companion key left behind by Del is reaped, not the new value.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := kv.Get("key\x00skytable:expiry"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expiry key was not deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if val, ok := kv.Get("key"); !ok || val != "new" {
+		t.Fatalf("got %q, %v, wanted %q", val, ok, "new")
+	}
+
+	// GetEx does not enforce a deadline left over from another value either.
+	if err := rdb.SetEx(ctx, "other", "old", ttl).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Del(ctx, "other").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Set(ctx, "other", "new").Err(); err != nil {
+		t.Fatal(err)
+	}
+	rdb.Close()
+	time.Sleep(2 * ttl)
+
+	rdb = skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+	if val, err := rdb.GetEx(ctx, "other", time.Hour).Result(); err != nil || val != "new" {
+		t.Fatalf("got %q, %v, wanted %q", val, err, "new")
+	}
+}
+
+func TestSetExOutlivesCallContext(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(withExpiry(srv.options()))
+	defer rdb.Close()
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	if err := rdb.WithContext(reqCtx).SetEx(ctx, "key", "value", 50*time.Millisecond).Err(); err != nil {
+		t.Fatal(err)
+	}
+	// The request is over before the key expires.
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := kv.Get("key"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("key did not expire after the context of SetEx was canceled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+var _ = Describe("Expiry", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(withExpiry(skytableOptions()))
+		Expect(client.FlushDB(ctx, "").Err()).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should SetEx", func() {
+		err := client.SetEx(ctx, "key", "hello", 100*time.Millisecond).Err()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("hello"))
+
+		Eventually(func() error {
+			return client.Get(ctx, "key").Err()
+		}).Should(Equal(skytable.Nil))
+	})
+
+	It("should overwrite with SetEx", func() {
+		Expect(client.Set(ctx, "key", "hello").Err()).NotTo(HaveOccurred())
+
+		err := client.SetEx(ctx, "key", "world", time.Hour).Err()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Get(ctx, "key").Val()).To(Equal("world"))
+	})
+
+	It("should GetEx", func() {
+		err := client.SetEx(ctx, "key", "hello", 200*time.Millisecond).Err()
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 5; i++ {
+			time.Sleep(100 * time.Millisecond)
+			Expect(client.GetEx(ctx, "key", 200*time.Millisecond).Val()).To(Equal("hello"))
+		}
+
+		Eventually(func() error {
+			return client.Get(ctx, "key").Err()
+		}).Should(Equal(skytable.Nil))
+	})
+})
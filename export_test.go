@@ -7,3 +7,7 @@ import (
 func (c *baseClient) Pool() pool.Pooler {
 	return c.connPool
 }
+
+func (c *baseClient) SetOnClose(fn func() error) {
+	c.onClose = fn
+}
@@ -35,7 +35,7 @@ func AppendArg(b []byte, v interface{}) []byte {
 	case uint64:
 		return strconv.AppendUint(b, v, 10)
 	case float32:
-		return strconv.AppendFloat(b, float64(v), 'f', -1, 64)
+		return strconv.AppendFloat(b, float64(v), 'f', -1, 32)
 	case float64:
 		return strconv.AppendFloat(b, v, 'f', -1, 64)
 	case bool:
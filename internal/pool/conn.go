@@ -14,6 +14,8 @@ var noDeadline = time.Time{}
 
 type Conn struct {
 	usedAt  int64 // atomic
+	uses    int64 // atomic
+	rtt     int64 // atomic, nanoseconds
 	netConn net.Conn
 
 	rd *proto.Reader
@@ -23,14 +25,58 @@ type Conn struct {
 	Inited    bool
 	pooled    bool
 	createdAt time.Time
+	// ageJitter is subtracted from Options.MaxConnAge for this connection.
+	ageJitter time.Duration
+
+	// Generation is set by the client when it initializes the connection,
+	// to tell connections initialized with outdated settings apart.
+	Generation uint32
+
+	// hasReadDeadline and hasWriteDeadline report whether a deadline is set
+	// on netConn and must be cleared before an operation without one.
+	hasReadDeadline  bool
+	hasWriteDeadline bool
+
+	// The goroutine of watcher is started by the first call to Watch and
+	// stopped by Close.
+	watcher     connWatcher
+	watching    uint32 // atomic
+	interrupted uint32 // atomic
+	closed      uint32 // atomic
+}
+
+// connWatcher is the goroutine interrupting the I/O of a connection when
+// the done channel it watches is closed. It serves every command of the
+// connection, instead of a goroutine being started for each of them.
+type connWatcher struct {
+	watch chan (<-chan struct{})
+	stop  chan struct{}
+	fired chan bool
+	quit  chan struct{}
 }
 
+// interruptDeadline is a deadline in the past, which makes pending and
+// future I/O fail at once.
+var interruptDeadline = time.Unix(1, 0)
+
 func NewConn(netConn net.Conn) *Conn {
+	return NewConnWithBufferSize(netConn, proto.DefaultBufferSize, proto.DefaultBufferSize)
+}
+
+// NewConnWithBufferSize returns a Conn whose read buffer starts at
+// readBufSize bytes and grows up to maxReadBufSize bytes for large replies.
+func NewConnWithBufferSize(netConn net.Conn, readBufSize, maxReadBufSize int) *Conn {
 	cn := &Conn{
 		netConn:   netConn,
 		createdAt: time.Now(),
+		watcher: connWatcher{
+			watch: make(chan (<-chan struct{})),
+			stop:  make(chan struct{}),
+			fired: make(chan bool, 1),
+			quit:  make(chan struct{}),
+		},
 	}
-	cn.rd = proto.NewReader(netConn)
+	cn.rd = proto.NewReaderSize(netConn, readBufSize, maxReadBufSize)
 	cn.bw = bufio.NewWriter(netConn)
 	cn.wr = proto.NewWriter(cn.bw)
 	cn.SetUsedAt(time.Now())
@@ -46,6 +92,30 @@ func (cn *Conn) SetUsedAt(tm time.Time) {
 	atomic.StoreInt64(&cn.usedAt, tm.Unix())
 }
 
+// IncrUses increments the number of commands served by the connection
+// and returns the new count.
+func (cn *Conn) IncrUses() int {
+	return int(atomic.AddInt64(&cn.uses, 1))
+}
+
+// RecordRTT adds the round-trip time of a command to the moving average
+// returned by RTT. Like the smoothed RTT of TCP, each sample weighs 1/8.
+func (cn *Conn) RecordRTT(d time.Duration) {
+	rtt := atomic.LoadInt64(&cn.rtt)
+	if rtt == 0 {
+		rtt = int64(d)
+	} else {
+		rtt += (int64(d) - rtt) / 8
+	}
+	atomic.StoreInt64(&cn.rtt, rtt)
+}
+
+// RTT returns the moving average of the round-trip times recorded, or 0
+// if none was.
+func (cn *Conn) RTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&cn.rtt))
+}
+
 func (cn *Conn) SetNetConn(netConn net.Conn) {
 	cn.netConn = netConn
 	cn.rd.Reset(netConn)
@@ -63,22 +133,31 @@ func (cn *Conn) RemoteAddr() net.Addr {
 	return nil
 }
 
+// WithReader runs fn with a read deadline set to the earliest of timeout
+// and the ctx deadline. A zero timeout only applies the ctx deadline.
 func (cn *Conn) WithReader(ctx context.Context, timeout time.Duration, fn func(rd *proto.Reader) error) error {
-	if timeout != 0 {
-		if err := cn.netConn.SetReadDeadline(cn.deadline(ctx, timeout)); err != nil {
+	if tm := cn.deadline(ctx, timeout); tm != noDeadline || cn.hasReadDeadline {
+		if err := cn.netConn.SetReadDeadline(tm); err != nil {
 			return err
 		}
+		cn.hasReadDeadline = tm != noDeadline
+		cn.keepInterrupted()
 	}
-	return fn(cn.rd)
+	err := fn(cn.rd)
+	cn.rd.AdjustBuffer()
+	return err
 }
 
+// WithWriter is like WithReader for the write deadline.
 func (cn *Conn) WithWriter(
 	ctx context.Context, timeout time.Duration, fn func(wr *proto.Writer) error,
 ) error {
-	if timeout != 0 {
-		if err := cn.netConn.SetWriteDeadline(cn.deadline(ctx, timeout)); err != nil {
+	if tm := cn.deadline(ctx, timeout); tm != noDeadline || cn.hasWriteDeadline {
+		if err := cn.netConn.SetWriteDeadline(tm); err != nil {
 			return err
 		}
+		cn.hasWriteDeadline = tm != noDeadline
+		cn.keepInterrupted()
 	}
 
 	if cn.bw.Buffered() > 0 {
@@ -93,9 +172,70 @@ func (cn *Conn) WithWriter(
 }
 
 func (cn *Conn) Close() error {
+	if atomic.CompareAndSwapUint32(&cn.closed, 0, 1) {
+		close(cn.watcher.quit)
+	}
 	return cn.netConn.Close()
 }
 
+// Watch makes the I/O of cn fail once done is closed, until Unwatch is
+// called. A connection interrupted that way must not be reused.
+func (cn *Conn) Watch(done <-chan struct{}) {
+	if atomic.CompareAndSwapUint32(&cn.watching, 0, 1) {
+		go cn.watch(&cn.watcher)
+	}
+	select {
+	case cn.watcher.watch <- done:
+	case <-cn.watcher.quit:
+	}
+}
+
+// Unwatch stops watching the channel passed to Watch and reports whether
+// it was closed in the meantime, interrupting cn.
+func (cn *Conn) Unwatch() bool {
+	select {
+	case cn.watcher.stop <- struct{}{}:
+	case <-cn.watcher.quit:
+		return atomic.LoadUint32(&cn.interrupted) == 1
+	}
+	return <-cn.watcher.fired
+}
+
+func (cn *Conn) watch(w *connWatcher) {
+	for {
+		var done <-chan struct{}
+		select {
+		case done = <-w.watch:
+		case <-w.quit:
+			return
+		}
+
+		select {
+		case <-done:
+			atomic.StoreUint32(&cn.interrupted, 1)
+			_ = cn.netConn.SetDeadline(interruptDeadline)
+			select {
+			case <-w.stop:
+			case <-w.quit:
+				return
+			}
+			w.fired <- true
+		case <-w.stop:
+			w.fired <- false
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// keepInterrupted restores the deadline set by the watcher if it
+// interrupted cn while a new deadline was being set.
+func (cn *Conn) keepInterrupted() {
+	if atomic.LoadUint32(&cn.interrupted) == 1 {
+		_ = cn.netConn.SetDeadline(interruptDeadline)
+	}
+}
+
 func (cn *Conn) deadline(ctx context.Context, timeout time.Duration) time.Time {
 	tm := time.Now()
 	cn.SetUsedAt(tm)
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"net"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/satvik007/skytable-go/internal"
+	"github.com/satvik007/skytable-go/internal/rand"
 )
 
 var (
@@ -53,6 +55,17 @@ type Pooler interface {
 	Close() error
 }
 
+// IdleReapStrategy selects which stale idle connections are reaped when
+// there are more than Options.IdleReapLimit.
+type IdleReapStrategy int
+
+const (
+	// LongestIdle reaps the connection used least recently first.
+	LongestIdle IdleReapStrategy = iota
+	// OldestCreated reaps the connection dialed earliest first.
+	OldestCreated
+)
+
 type Options struct {
 	Dialer  func(context.Context) (net.Conn, error)
 	OnClose func(*Conn) error
@@ -61,9 +74,16 @@ type Options struct {
 	PoolSize           int
 	MinIdleConns       int
 	MaxConnAge         time.Duration
+	MaxConnAgeJitter   time.Duration
 	PoolTimeout        time.Duration
 	IdleTimeout        time.Duration
 	IdleCheckFrequency time.Duration
+	IdleReapLimit      int
+	IdleReapStrategy   IdleReapStrategy
+
+	ReadBufferSize    int
+	MaxReadBufferSize int
+	MaxReplySize      int
 }
 
 type lastDialErrorWrap struct {
@@ -204,8 +224,12 @@ func (p *ConnPool) dialConn(ctx context.Context, pooled bool) (*Conn, error) {
 		return nil, err
 	}
 
-	cn := NewConn(netConn)
+	cn := NewConnWithBufferSize(netConn, p.opt.ReadBufferSize, p.opt.MaxReadBufferSize)
+	cn.rd.SetMaxReplySize(p.opt.MaxReplySize)
 	cn.pooled = pooled
+	if p.opt.MaxConnAgeJitter > 0 {
+		cn.ageJitter = time.Duration(rand.Int63n(int64(p.opt.MaxConnAgeJitter)))
+	}
 	return cn, nil
 }
 
@@ -436,6 +460,21 @@ func (p *ConnPool) Stats() *Stats {
 	}
 }
 
+// RTTs returns the round-trip time of every connection of the pool that
+// has one recorded, see Conn.RTT.
+func (p *ConnPool) RTTs() []time.Duration {
+	p.connsMu.Lock()
+	defer p.connsMu.Unlock()
+
+	rtts := make([]time.Duration, 0, len(p.conns))
+	for _, cn := range p.conns {
+		if rtt := cn.RTT(); rtt > 0 {
+			rtts = append(rtts, rtt)
+		}
+	}
+	return rtts
+}
+
 func (p *ConnPool) closed() bool {
 	return atomic.LoadUint32(&p._closed) == 1
 }
@@ -502,42 +541,69 @@ func (p *ConnPool) reaper(frequency time.Duration) {
 }
 
 func (p *ConnPool) ReapStaleConns() (int, error) {
-	var n int
-	for {
-		p.getTurn()
+	p.getTurn()
 
-		p.connsMu.Lock()
-		cn := p.reapStaleConn()
-		p.connsMu.Unlock()
+	p.connsMu.Lock()
+	stale := p.reapStaleConns()
+	p.connsMu.Unlock()
 
-		p.freeTurn()
+	p.freeTurn()
 
-		if cn != nil {
-			_ = p.closeConn(cn)
-			n++
-		} else {
-			break
-		}
+	for _, cn := range stale {
+		_ = p.closeConn(cn)
 	}
-	atomic.AddUint32(&p.stats.StaleConns, uint32(n))
-	return n, nil
+	atomic.AddUint32(&p.stats.StaleConns, uint32(len(stale)))
+	return len(stale), nil
 }
 
-func (p *ConnPool) reapStaleConn() *Conn {
-	if len(p.idleConns) == 0 {
+// reapStaleConns removes the stale idle connections, at most
+// Options.IdleReapLimit of them chosen by Options.IdleReapStrategy, and
+// returns them. Every idle connection is checked once: connections retired
+// by MaxConnAge or a failed connCheck can be anywhere in idleConns.
+func (p *ConnPool) reapStaleConns() []*Conn {
+	var stale []*Conn
+	for _, cn := range p.idleConns {
+		if p.isStaleConn(cn) {
+			stale = append(stale, cn)
+		}
+	}
+	if len(stale) == 0 {
 		return nil
 	}
+	if n := p.opt.IdleReapLimit; n > 0 && len(stale) > n {
+		sort.SliceStable(stale, func(i, j int) bool {
+			return p.reapBefore(stale[i], stale[j])
+		})
+		stale = stale[:n]
+	}
 
-	cn := p.idleConns[0]
-	if !p.isStaleConn(cn) {
-		return nil
+	reaped := make(map[*Conn]struct{}, len(stale))
+	for _, cn := range stale {
+		reaped[cn] = struct{}{}
+		p.removeConn(cn)
+	}
+	idle := p.idleConns[:0]
+	for _, cn := range p.idleConns {
+		if _, ok := reaped[cn]; !ok {
+			idle = append(idle, cn)
+		}
+	}
+	// Don't keep the removed connections reachable from the backing array.
+	for i := len(idle); i < len(p.idleConns); i++ {
+		p.idleConns[i] = nil
 	}
+	p.idleConns = idle
+	p.idleConnsLen -= len(stale)
 
-	p.idleConns = append(p.idleConns[:0], p.idleConns[1:]...)
-	p.idleConnsLen--
-	p.removeConn(cn)
+	return stale
+}
 
-	return cn
+// reapBefore reports whether cn should be reaped before other.
+func (p *ConnPool) reapBefore(cn, other *Conn) bool {
+	if p.opt.IdleReapStrategy == OldestCreated {
+		return cn.createdAt.Before(other.createdAt)
+	}
+	return cn.UsedAt().Before(other.UsedAt())
 }
 
 func (p *ConnPool) isStaleConn(cn *Conn) bool {
@@ -549,7 +615,7 @@ func (p *ConnPool) isStaleConn(cn *Conn) bool {
 	if p.opt.IdleTimeout > 0 && now.Sub(cn.UsedAt()) >= p.opt.IdleTimeout {
 		return true
 	}
-	if p.opt.MaxConnAge > 0 && now.Sub(cn.createdAt) >= p.opt.MaxConnAge {
+	if p.opt.MaxConnAge > 0 && now.Sub(cn.createdAt) >= p.opt.MaxConnAge-cn.ageJitter {
 		return true
 	}
 
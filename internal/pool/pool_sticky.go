@@ -13,6 +13,11 @@ const (
 	stateClosed  = 2
 )
 
+// ErrBadConn is matched by errors.Is for every BadConnError.
+var ErrBadConn = errors.New("skytable: Conn is in a bad state")
+
+// BadConnError is returned by StickyConnPool.Get once its connection was
+// removed, e.g. because it broke, until Reset or Release is called.
 type BadConnError struct {
 	wrapped error
 }
@@ -20,7 +25,7 @@ type BadConnError struct {
 var _ error = (*BadConnError)(nil)
 
 func (e BadConnError) Error() string {
-	s := "skytable: Conn is in a bad state"
+	s := ErrBadConn.Error()
 	if e.wrapped != nil {
 		s += ": " + e.wrapped.Error()
 	}
@@ -31,6 +36,10 @@ func (e BadConnError) Unwrap() error {
 	return e.wrapped
 }
 
+func (e BadConnError) Is(target error) bool {
+	return target == ErrBadConn
+}
+
 // ------------------------------------------------------------------------------
 
 type StickyConnPool struct {
@@ -170,6 +179,35 @@ func (p *StickyConnPool) Reset(ctx context.Context) error {
 	return nil
 }
 
+// Release returns the pinned connection to the parent pool, or closes it
+// if it went bad. The next Get pins a connection again. It fails if the
+// connection is in use.
+func (p *StickyConnPool) Release(ctx context.Context) error {
+	switch atomic.LoadUint32(&p.state) {
+	case stateDefault:
+		return nil
+	case stateClosed:
+		return ErrClosed
+	}
+
+	select {
+	case cn, ok := <-p.ch:
+		if !ok {
+			return ErrClosed
+		}
+		p.freeConn(ctx, cn)
+		p._badConnError.Store(BadConnError{wrapped: nil})
+	default:
+		return errors.New("skytable: StickyConnPool connection is in use")
+	}
+
+	if !atomic.CompareAndSwapUint32(&p.state, stateInited, stateDefault) {
+		state := atomic.LoadUint32(&p.state)
+		return fmt.Errorf("skytable: invalid StickyConnPool state: %d", state)
+	}
+	return nil
+}
+
 func (p *StickyConnPool) badConnError() error {
 	if v := p._badConnError.Load(); v != nil {
 		if err := v.(BadConnError); err.wrapped != nil {
@@ -287,6 +287,149 @@ var _ = Describe("MinIdleConns", func() {
 	})
 })
 
+var _ = Describe("MaxConnAgeJitter", func() {
+	const maxAge = time.Hour
+	const jitter = 30 * time.Minute
+
+	ctx := context.Background()
+
+	It("retires connections dialed together at different ages", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           20,
+			MaxConnAge:         maxAge,
+			MaxConnAgeJitter:   jitter,
+			PoolTimeout:        time.Second,
+			IdleCheckFrequency: time.Hour,
+		})
+		defer connPool.Close()
+
+		var cns []*pool.Conn
+		for i := 0; i < 20; i++ {
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+		for _, cn := range cns {
+			connPool.Put(ctx, cn)
+		}
+
+		// Halfway through the jitter band, only some connections are stale.
+		for _, cn := range cns {
+			cn.SetCreatedAt(time.Now().Add(-maxAge + jitter/2))
+		}
+		n, err := connPool.ReapStaleConns()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(BeNumerically(">", 0))
+		Expect(n).To(BeNumerically("<", 20))
+
+		// Past MaxConnAge, all of them are.
+		for _, cn := range cns {
+			cn.SetCreatedAt(time.Now().Add(-maxAge))
+		}
+		m, err := connPool.ReapStaleConns()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n + m).To(Equal(20))
+	})
+})
+
+var _ = Describe("IdleReapStrategy", func() {
+	const idleTimeout = time.Minute
+
+	ctx := context.Background()
+
+	// reaped returns the connections reaped among three stale ones, with
+	// IdleReapLimit set to reap only one: the first connection was dialed
+	// earliest, the second one has been idle the longest.
+	reaped := func(strategy pool.IdleReapStrategy) []int {
+		var closed []*pool.Conn
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			IdleTimeout:        idleTimeout,
+			PoolTimeout:        time.Second,
+			IdleCheckFrequency: time.Hour,
+			IdleReapLimit:      1,
+			IdleReapStrategy:   strategy,
+			OnClose: func(cn *pool.Conn) error {
+				closed = append(closed, cn)
+				return nil
+			},
+		})
+		defer connPool.Close()
+
+		var cns []*pool.Conn
+		for i := 0; i < 3; i++ {
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+		for _, cn := range cns {
+			connPool.Put(ctx, cn)
+		}
+
+		now := time.Now()
+		cns[0].SetCreatedAt(now.Add(-time.Hour))
+		cns[0].SetUsedAt(now.Add(-2 * idleTimeout))
+		cns[1].SetCreatedAt(now.Add(-30 * time.Minute))
+		cns[1].SetUsedAt(now.Add(-10 * idleTimeout))
+		cns[2].SetCreatedAt(now.Add(-20 * time.Minute))
+		cns[2].SetUsedAt(now.Add(-5 * idleTimeout))
+
+		n, err := connPool.ReapStaleConns()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(1))
+		Expect(connPool.Len()).To(Equal(2))
+		Expect(connPool.IdleLen()).To(Equal(2))
+
+		var got []int
+		for _, cn := range closed {
+			for j := range cns {
+				if cn == cns[j] {
+					got = append(got, j)
+				}
+			}
+		}
+		return got
+	}
+
+	It("reaps the longest idle connection by default", func() {
+		Expect(reaped(pool.LongestIdle)).To(Equal([]int{1}))
+	})
+
+	It("reaps the oldest connection with OldestCreated", func() {
+		Expect(reaped(pool.OldestCreated)).To(Equal([]int{0}))
+	})
+
+	It("reaps every stale connection without a limit", func() {
+		connPool := pool.NewConnPool(&pool.Options{
+			Dialer:             dummyDialer,
+			PoolSize:           10,
+			IdleTimeout:        idleTimeout,
+			PoolTimeout:        time.Second,
+			IdleCheckFrequency: time.Hour,
+			IdleReapStrategy:   pool.OldestCreated,
+		})
+		defer connPool.Close()
+
+		var cns []*pool.Conn
+		for i := 0; i < 3; i++ {
+			cn, err := connPool.Get(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			cns = append(cns, cn)
+		}
+		for _, cn := range cns {
+			connPool.Put(ctx, cn)
+			cn.SetUsedAt(time.Now().Add(-2 * idleTimeout))
+		}
+
+		n, err := connPool.ReapStaleConns()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(3))
+		Expect(connPool.Len()).To(Equal(0))
+	})
+})
+
 var _ = Describe("conns reaper", func() {
 	const idleTimeout = time.Minute
 	const maxAge = time.Hour
@@ -414,6 +557,66 @@ var _ = Describe("conns reaper", func() {
 	assert("connCheck")
 })
 
+var _ = Describe("pool mode", func() {
+	ctx := context.Background()
+
+	for _, fifo := range []bool{false, true} {
+		fifo := fifo
+		mode := map[bool]string{false: "LIFO", true: "FIFO"}[fifo]
+
+		Describe(mode, func() {
+			var connPool *pool.ConnPool
+			var conns []*pool.Conn
+
+			BeforeEach(func() {
+				connPool = pool.NewConnPool(&pool.Options{
+					Dialer:             dummyDialer,
+					PoolFIFO:           fifo,
+					PoolSize:           10,
+					MaxConnAge:         time.Hour,
+					PoolTimeout:        time.Second,
+					IdleCheckFrequency: time.Hour,
+				})
+
+				conns = nil
+				for i := 0; i < 3; i++ {
+					cn, err := connPool.Get(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					conns = append(conns, cn)
+				}
+				for _, cn := range conns {
+					connPool.Put(ctx, cn)
+				}
+			})
+
+			AfterEach(func() {
+				_ = connPool.Close()
+			})
+
+			It("reuses connections in order", func() {
+				cn, err := connPool.Get(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				if fifo {
+					Expect(cn).To(Equal(conns[0]))
+				} else {
+					Expect(cn).To(Equal(conns[2]))
+				}
+				connPool.Put(ctx, cn)
+			})
+
+			It("reaps stale connections at the back", func() {
+				conns[2].SetCreatedAt(time.Now().Add(-2 * time.Hour))
+
+				n, err := connPool.ReapStaleConns()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(n).To(Equal(1))
+				Expect(connPool.Len()).To(Equal(2))
+				Expect(connPool.IdleLen()).To(Equal(2))
+			})
+		})
+	}
+})
+
 var _ = Describe("race", func() {
 	ctx := context.Background()
 	var connPool *pool.ConnPool
@@ -2,6 +2,8 @@ package proto
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -22,6 +24,25 @@ const UnknownDataTypeError = SkytableError("skytable: unknown data type")
 const EncodingError = SkytableError("skytable: encoding error")
 const BadCredentials = SkytableError("skytable: bad credentials")
 const AuthnRealmError = SkytableError("skytable: authn realm error")
+const UnknownActionError = SkytableError("skytable: unknown action")
+const AlreadyExistsError = SkytableError("skytable: already exists")
+const UnknownInspectQueryError = SkytableError("skytable: unknown inspect query")
+const ContainerNotFoundError = SkytableError("skytable: container not found")
+const StillInUseError = SkytableError("skytable: still in use")
+const KeyspaceNotEmptyError = SkytableError("skytable: keyspace not empty")
+const BadContainerNameError = SkytableError("skytable: bad container name")
+const ContainerNameTooLongError = SkytableError("skytable: container name too long")
+const TooManyArgsError = SkytableError("skytable: too many args")
+const UnknownPropertyError = SkytableError("skytable: unknown property")
+const UnknownModelError = SkytableError("skytable: unknown model")
+const ProtectedObjectError = SkytableError("skytable: protected object, not accessible to users")
+const WrongModelError = SkytableError("skytable: wrong model")
+const AuthDisabledError = SkytableError("skytable: auth disabled")
+const AuthAlreadyClaimedError = SkytableError("skytable: auth already claimed")
+const AuthIllegalUsernameError = SkytableError("skytable: illegal username")
+const AuthDelUserFailError = SkytableError("skytable: user can't be removed")
+const PipelineNotSupportedError = SkytableError("skytable: pipelining not supported by this server version")
+const DefaultContainerUnsetError = SkytableError("skytable: default container unset, select a table with USE or Options.Table")
 
 var CodeToErrorMap = map[int64]SkytableError{
 	1:  Nil,
@@ -37,6 +58,32 @@ var CodeToErrorMap = map[int64]SkytableError{
 	11: AuthnRealmError,
 }
 
+// StringToErrorMap maps the string error replies sent in place of
+// a status code to their errors.
+var StringToErrorMap = map[string]SkytableError{
+	"Unknown action":          UnknownActionError,
+	"err-already-exists":      AlreadyExistsError,
+	"unknown-inspect-query":   UnknownInspectQueryError,
+	"container-not-found":     ContainerNotFoundError,
+	"still-in-use":            StillInUseError,
+	"keyspace-not-empty":      KeyspaceNotEmptyError,
+	"default-container-unset": DefaultContainerUnsetError,
+	"bad-container-name":      BadContainerNameError,
+	"container-name-too-long": ContainerNameTooLongError,
+	"too-many-args":           TooManyArgsError,
+	"unknown-property":        UnknownPropertyError,
+	"unknown-model":           UnknownModelError,
+	"err-protected-object":    ProtectedObjectError,
+	"wrong-model":             WrongModelError,
+
+	"pipeline-not-supported-yet": PipelineNotSupportedError,
+
+	"err-auth-disabled":         AuthDisabledError,
+	"err-auth-already-claimed":  AuthAlreadyClaimedError,
+	"err-auth-illegal-username": AuthIllegalUsernameError,
+	"err-auth-deluser-fail":     AuthDelUserFailError,
+}
+
 const (
 	RespString            = '+' // +<length>\n<bytes>\n
 	RespArray             = '&' // &<c>\n<elements>
@@ -49,6 +96,9 @@ const (
 	RespBlob              = '?' // ?<length>\n<bytes>
 	RespStatus            = '!' // !<length>\n<statusCode>\n
 	RespMetaFrame         = '*' // *<number>\n
+	// RespAttr prefixes a reply with <c> key/value pairs of metadata. It
+	// is not part of the reply, and current servers don't send it.
+	RespAttr = '|' // |<c>\n<key><value>...
 )
 
 type SkytableError string
@@ -58,21 +108,221 @@ func (e SkytableError) Error() string { return string(e) }
 func (SkytableError) SkytableError() {}
 
 func ParseErrorReply(line []byte) error {
+	if len(line) < 2 {
+		return protocolError("invalid error reply: %q", line)
+	}
 	return SkytableError(line[1:])
 }
 
+// ProtocolError is returned when a reply doesn't follow the Skyhash
+// protocol, e.g. because the stream is corrupt. The connection it was read
+// from is out of sync and must not be reused.
+type ProtocolError string
+
+func (e ProtocolError) Error() string { return string(e) }
+
+func protocolError(format string, args ...interface{}) error {
+	return ProtocolError("skytable: " + fmt.Sprintf(format, args...))
+}
+
+// ErrReplyTypeMismatch is matched by errors.Is for every
+// *ReplyTypeMismatchError.
+var ErrReplyTypeMismatch = errors.New("skytable: reply type mismatch")
+
+// ReplyTypeMismatchError is returned when a reply has another type than
+// the one being read, e.g. an int reply read with ReadString. The payload
+// of the reply is left unread.
+type ReplyTypeMismatchError struct {
+	// Expected and Actual are type bytes such as RespString.
+	Expected byte
+	Actual   byte
+}
+
+func (e *ReplyTypeMismatchError) Error() string {
+	return fmt.Sprintf("skytable: can't read %q reply as %q", e.Actual, e.Expected)
+}
+
+func (e *ReplyTypeMismatchError) Is(target error) bool {
+	return target == ErrReplyTypeMismatch
+}
+
+func typeMismatch(expected byte, line []byte) error {
+	return &ReplyTypeMismatchError{Expected: expected, Actual: line[0]}
+}
+
+// ErrReplyTooLarge is matched by errors.Is for every *ReplyTooLargeError.
+var ErrReplyTooLarge = errors.New("skytable: reply too large")
+
+// ReplyTooLargeError is returned when a reply header announces a length,
+// or an element count, above the limit set with SetMaxReplySize. The
+// payload is left unread, so the stream is out of sync.
+type ReplyTooLargeError struct {
+	Len int
+	Max int
+}
+
+func (e *ReplyTooLargeError) Error() string {
+	return fmt.Sprintf("skytable: reply of length %d exceeds the maximum of %d", e.Len, e.Max)
+}
+
+func (e *ReplyTooLargeError) Is(target error) bool {
+	return target == ErrReplyTooLarge
+}
+
+// maxPrealloc is the largest number of elements or bytes allocated up front
+// for a reply. Larger replies grow as they are read, so a bogus length
+// can't make the reader allocate memory the stream doesn't fill.
+const maxPrealloc = 64 * 1024
+
+// PreallocLen returns the capacity to allocate up front for a reply of n
+// elements. Callers append beyond it as the elements are read.
+func PreallocLen(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
+	}
+	return n
+}
+
 // ------------------------------------------------------------------------------
 
+// DefaultBufferSize is the size of the read buffer of a Reader created
+// with NewReader.
+const DefaultBufferSize = 4096
+
+// minBufferSize is the smallest buffer bufio allocates.
+const minBufferSize = 16
+
+// bufferShrinkAfter is the number of replies in a row that fit in the
+// base buffer after which a grown buffer shrinks back to it. Waiting
+// keeps a connection that reads large replies regularly from reallocating
+// its buffer every time.
+const bufferShrinkAfter = 16
+
 type Reader struct {
-	rd *bufio.Reader
+	rd    *bufio.Reader
+	src   countingReader
+	attrs map[string]interface{}
+
+	// maxLen is the largest length or element count accepted in a reply
+	// header, or 0 for no limit.
+	maxLen int
+
+	// size is the size of rd, between baseSize and maxSize. peak is the
+	// largest reply line or payload read since the last AdjustBuffer,
+	// and small the number of calls in a row with a peak within baseSize.
+	size, baseSize, maxSize int
+	peak, small             int
 }
 
 func NewReader(rd io.Reader) *Reader {
-	return &Reader{
-		rd: bufio.NewReader(rd),
+	return NewReaderSize(rd, DefaultBufferSize, DefaultBufferSize)
+}
+
+// NewReaderSize returns a Reader whose buffer starts at size bytes and
+// grows up to maxSize bytes for larger replies. See AdjustBuffer.
+// A size of 0 means DefaultBufferSize.
+func NewReaderSize(rd io.Reader, size, maxSize int) *Reader {
+	if size <= 0 {
+		size = DefaultBufferSize
+	} else if size < minBufferSize {
+		size = minBufferSize
+	}
+	if maxSize < size {
+		maxSize = size
+	}
+	r := &Reader{src: countingReader{rd: rd}, size: size, baseSize: size, maxSize: maxSize}
+	r.rd = bufio.NewReaderSize(&r.src, size)
+	return r
+}
+
+// countingReader counts the bytes read from rd. The pending bytes were
+// read, and counted, but not consumed before the buffer was resized: they
+// are returned first.
+type countingReader struct {
+	rd      io.Reader
+	n       int64
+	pending []byte
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	if len(r.pending) > 0 {
+		n := copy(b, r.pending)
+		r.pending = r.pending[n:]
+		return n, nil
+	}
+	n, err := r.rd.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// Consumed returns the number of bytes of replies read so far, not
+// counting the bytes that are buffered but not read yet.
+func (r *Reader) Consumed() int64 {
+	return r.src.n - int64(len(r.src.pending)) - int64(r.rd.Buffered())
+}
+
+// SetMaxReplySize makes the Reader fail with a *ReplyTooLargeError when a
+// reply header announces more than n bytes or elements, before anything
+// is allocated for it. Every element takes at least a byte, so n bounds
+// both. 0 removes the limit.
+func (r *Reader) SetMaxReplySize(n int) {
+	r.maxLen = n
+}
+
+// BufferSize returns the current size of the read buffer.
+func (r *Reader) BufferSize() int {
+	return r.size
+}
+
+// AdjustBuffer is called once the replies of a command or pipeline are
+// read. A buffer grown for a large reply shrinks back to its base size
+// once bufferShrinkAfter calls in a row saw no reply larger than it.
+func (r *Reader) AdjustBuffer() {
+	peak := r.peak
+	r.peak = 0
+	if r.size == r.baseSize {
+		return
+	}
+	if peak > r.baseSize {
+		r.small = 0
+		return
+	}
+	if r.small++; r.small >= bufferShrinkAfter {
+		r.small = 0
+		r.resize(r.baseSize)
 	}
 }
 
+// sawReply records a reply line or payload of n bytes and grows the
+// buffer, doubling it until it holds n bytes or reaches maxSize.
+func (r *Reader) sawReply(n int) {
+	if n > r.peak {
+		r.peak = n
+	}
+	if n <= r.size || r.size == r.maxSize {
+		return
+	}
+	size := r.size
+	for size < n && size < r.maxSize {
+		size *= 2
+	}
+	if size > r.maxSize {
+		size = r.maxSize
+	}
+	r.resize(size)
+}
+
+// resize replaces the buffer with one of size bytes, keeping the bytes
+// buffered but not read yet.
+func (r *Reader) resize(size int) {
+	if n := r.rd.Buffered(); n > 0 {
+		b, _ := r.rd.Peek(n)
+		r.src.pending = append(append([]byte(nil), b...), r.src.pending...)
+	}
+	r.rd = bufio.NewReaderSize(&r.src, size)
+	r.size = size
+}
+
 func (r *Reader) Buffered() int {
 	return r.rd.Buffered()
 }
@@ -82,32 +332,105 @@ func (r *Reader) Peek(n int) ([]byte, error) {
 }
 
 func (r *Reader) Reset(rd io.Reader) {
-	r.rd.Reset(rd)
+	r.src = countingReader{rd: rd}
+	if r.size != r.baseSize {
+		r.rd = bufio.NewReaderSize(&r.src, r.baseSize)
+		r.size = r.baseSize
+	} else {
+		r.rd.Reset(&r.src)
+	}
+	r.attrs = nil
+	r.peak, r.small = 0, 0
 }
 
 // PeekReplyType returns the data type of the next response without advancing the Reader,
 // and discard the attribute type.
 func (r *Reader) PeekReplyType() (byte, error) {
-	b, err := r.rd.Peek(1)
-	if err != nil {
-		return 0, err
+	for {
+		b, err := r.rd.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if b[0] != RespAttr {
+			return b[0], nil
+		}
+
+		line, err := r.readLine()
+		if err != nil {
+			return 0, err
+		}
+		if err := r.readAttrs(line); err != nil {
+			return 0, err
+		}
 	}
-	return b[0], nil
 }
 
 // ReadLine Return a valid reply, it will check the protocol or skytable error,
 // and discard the attribute type.
 func (r *Reader) ReadLine() ([]byte, error) {
-	line, err := r.readLine()
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line[0] != RespAttr {
+			return line, nil
+		}
+		if err := r.readAttrs(line); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readAttrs reads the key/value pairs of an attribute frame and keeps
+// them until TakeAttrs is called.
+func (r *Reader) readAttrs(line []byte) error {
+	n, err := r.replyLen(line)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return line, nil
+	for i := 0; i < n; i++ {
+		key, err := r.ReadReply()
+		if err != nil {
+			return err
+		}
+		val, err := r.ReadReply()
+		if err != nil {
+			return err
+		}
+
+		var k string
+		switch key := key.(type) {
+		case string:
+			k = key
+		case []byte:
+			k = string(key)
+		default:
+			return protocolError("invalid attribute key: %v", key)
+		}
+
+		if r.attrs == nil {
+			r.attrs = make(map[string]interface{})
+		}
+		r.attrs[k] = val
+	}
+	return nil
+}
+
+// TakeAttrs returns the attributes read since the last call, or nil if
+// there were none.
+func (r *Reader) TakeAttrs() map[string]interface{} {
+	attrs := r.attrs
+	r.attrs = nil
+	return attrs
 }
 
 // readLine returns an error if:
 //   - there is a pending read error;
-//   - or line does not end with \r\n.
+//   - or line does not end with \n;
+//   - or line is empty.
+//
+// The line returned never is, so callers may check its first byte.
 func (r *Reader) readLine() ([]byte, error) {
 	b, err := r.rd.ReadSlice('\n')
 	if err != nil {
@@ -125,9 +448,10 @@ func (r *Reader) readLine() ([]byte, error) {
 
 		full = append(full, b...) //nolint:makezero
 		b = full
+		r.sawReply(len(b))
 	}
 	if len(b) <= 1 || b[len(b)-1] != '\n' {
-		return nil, fmt.Errorf("skytable: invalid reply: %q", b)
+		return nil, protocolError("invalid reply: %q", b)
 	}
 	return b[:len(b)-1], nil
 }
@@ -137,67 +461,100 @@ func (r *Reader) readInt() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return util.ParseInt(line[1:], 10, 64)
+	n, err := util.ParseInt(line, 10, 64)
+	if err != nil {
+		return 0, protocolError("invalid int reply: %.100q", line)
+	}
+	return n, nil
 }
 
-func (r *Reader) readFloat() (float32, error) {
+func (r *Reader) readFloat() (float64, error) {
 	line, err := r.readLine()
 	if err != nil {
 		return 0, err
 	}
-	v := string(line[1:])
-	switch string(line[1:]) {
+	v := string(line)
+	switch v {
 	case "inf":
-		return float32(math.Inf(1)), nil
+		return math.Inf(1), nil
 	case "-inf":
-		return float32(math.Inf(-1)), nil
+		return math.Inf(-1), nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, protocolError("invalid float reply: %.100q", line)
 	}
-	val, err := strconv.ParseFloat(v, 32)
-	return float32(val), err
+	return f, nil
 }
 
+// readString reads the payload of a string or blob reply. The string aliases
+// a buffer allocated for it alone, so it is never overwritten.
 func (r *Reader) readString(line []byte) (string, error) {
-	n, err := replyLen(line)
+	b, err := r.readBytes(line)
 	if err != nil {
 		return "", err
 	}
+	return util.BytesToString(b), nil
+}
+
+// readBytes reads the payload of a string or blob reply into a new buffer.
+func (r *Reader) readBytes(line []byte) ([]byte, error) {
+	n, err := r.replyLen(line)
+	if err != nil {
+		return nil, err
+	}
+
+	r.sawReply(n + 1)
+	if n > maxPrealloc {
+		// Grow the buffer as the payload arrives.
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r.rd, int64(n)); err != nil {
+			return nil, err
+		}
+		if _, err := r.rd.Discard(1); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
 
 	b := make([]byte, n+1)
 	_, err = io.ReadFull(r.rd, b)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return util.BytesToString(b[:n]), nil
+	return b[:n], nil
 }
 
 func (r *Reader) readSlice(line []byte) ([]interface{}, error) {
-	n, err := replyLen(line)
+	n, err := r.replyLen(line)
 	if err != nil {
 		return nil, err
 	}
 
-	val := make([]interface{}, n)
-	for i := 0; i < len(val); i++ {
+	val := make([]interface{}, 0, PreallocLen(n))
+	for i := 0; i < n; i++ {
 		v, err := r.ReadReply()
 		if err != nil {
 			if err == Nil {
-				val[i] = nil
+				val = append(val, nil)
 				continue
 			}
 			if err, ok := err.(SkytableError); ok {
-				val[i] = err
+				val = append(val, err)
 				continue
 			}
 			return nil, err
 		}
-		val[i] = v
+		val = append(val, v)
 	}
 	return val, nil
 }
 
+// readStatus returns the status code along with the error it maps to.
+// The code is 0 for string errors, which carry no numeric code.
 func (r *Reader) readStatus(line []byte) (int64, error) {
-	_, err := util.Atoi(line[1:])
+	_, err := r.replyLen(line)
 	if err != nil {
 		return 0, err
 	}
@@ -207,32 +564,51 @@ func (r *Reader) readStatus(line []byte) (int64, error) {
 	}
 	val, err := util.Atoi(line)
 	if err != nil {
-		return 0, fmt.Errorf("skytable: %.100q", line)
+		if err, ok := StringToErrorMap[string(line)]; ok {
+			return 0, err
+		}
+		// Keep the message of errors without a sentinel as-is.
+		return 0, SkytableError(line)
 	}
 	if val == 0 {
 		return 0, nil
 	} else if val == 1 {
-		return 0, Nil
+		return 1, Nil
 	} else if val < 12 {
-		return 0, CodeToErrorMap[int64(val)]
+		return int64(val), CodeToErrorMap[int64(val)]
 	} else {
-		return 0, fmt.Errorf("skytable: unknown error occured, message: %v", val)
+		return int64(val), fmt.Errorf("skytable: unknown error occured, message: %v", val)
 	}
 }
 
-func replyLen(line []byte) (n int, err error) {
+func parseLen(line []byte) (n int, err error) {
 	n, err = util.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return 0, protocolError("invalid reply: %.100q", line)
+	}
+	return n, nil
+}
+
+// replyLen parses the length or element count of a reply header and
+// checks it against the limit set with SetMaxReplySize.
+func (r *Reader) replyLen(line []byte) (int, error) {
+	n, err := parseLen(line)
 	if err != nil {
 		return 0, err
 	}
-
-	if n < 0 {
-		return 0, fmt.Errorf("skytable: invalid reply: %q", line)
+	if err := r.checkLen(n); err != nil {
+		return 0, err
 	}
-
 	return n, nil
 }
 
+func (r *Reader) checkLen(n int) error {
+	if r.maxLen > 0 && n > r.maxLen {
+		return &ReplyTooLargeError{Len: n, Max: r.maxLen}
+	}
+	return nil
+}
+
 // -------------------------------
 
 func (r *Reader) ReadInt() (int64, error) {
@@ -248,10 +624,10 @@ func (r *Reader) ReadInt() (int64, error) {
 	case RespInt:
 		return r.readInt()
 	}
-	return 0, fmt.Errorf("skytable: can't parse int reply: %.100q", line)
+	return 0, typeMismatch(RespInt, line)
 }
 
-func (r *Reader) ReadFloat() (float32, error) {
+func (r *Reader) ReadFloat() (float64, error) {
 	line, err := r.ReadLine()
 	if err != nil {
 		return 0, err
@@ -264,7 +640,7 @@ func (r *Reader) ReadFloat() (float32, error) {
 	case RespFloat:
 		return r.readFloat()
 	}
-	return 0, fmt.Errorf("skytable: can't parse float reply: %.100q", line)
+	return 0, typeMismatch(RespFloat, line)
 }
 
 func (r *Reader) ReadString() (string, error) {
@@ -281,7 +657,7 @@ func (r *Reader) ReadString() (string, error) {
 	case RespString, RespBlob:
 		return r.readString(line)
 	}
-	return "", fmt.Errorf("skytable: can't parse reply=%.100q reading string", line)
+	return "", typeMismatch(RespString, line)
 }
 
 func (r *Reader) ReadSlice() ([]interface{}, error) {
@@ -297,20 +673,54 @@ func (r *Reader) ReadSlice() ([]interface{}, error) {
 	case RespArray:
 		return r.readSlice(line)
 	}
-	return nil, fmt.Errorf("skytable: can't parse reply=%.100q reading slice", line)
+	return nil, typeMismatch(RespArray, line)
 }
 
 func (r *Reader) ReadStatus() (int64, error) {
-	line, err := r.readLine()
+	line, err := r.ReadLine()
 	if err != nil {
 		return 0, err
 	}
 	if line[0] != RespStatus {
-		return 0, fmt.Errorf("skytable: can't parse reply=%.100q reading slice", line)
+		return 0, typeMismatch(RespStatus, line)
 	}
 	return r.readStatus(line)
 }
 
+// ParseAll parses data as a sequence of replies, skipping meta frames,
+// and returns their values. Skytable errors, including Nil, are returned
+// as values in place of their reply; any other error stops parsing.
+func ParseAll(data []byte) ([]interface{}, error) {
+	r := NewReader(bytes.NewReader(data))
+
+	var vals []interface{}
+	for {
+		typ, err := r.PeekReplyType()
+		if err == io.EOF {
+			return vals, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if typ == RespMetaFrame {
+			if _, err := r.ReadMetaFrame(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		val, err := r.ReadReply()
+		if err != nil {
+			if _, ok := err.(SkytableError); !ok {
+				return nil, err
+			}
+			val = err
+		}
+		vals = append(vals, val)
+	}
+}
+
 func (r *Reader) ReadReply() (interface{}, error) {
 	line, err := r.ReadLine()
 	if err != nil {
@@ -327,11 +737,11 @@ func (r *Reader) ReadReply() (interface{}, error) {
 	case RespString:
 		return r.readString(line)
 	case RespBlob:
-		return r.readLine()
+		return r.readBytes(line)
 	case RespArray:
 		return r.readSlice(line)
 	}
-	return nil, fmt.Errorf("skytable: can't parse %.100q", line)
+	return nil, protocolError("can't parse %.100q", line)
 }
 
 func (r *Reader) ReadMetaFrame() (int, error) {
@@ -340,11 +750,127 @@ func (r *Reader) ReadMetaFrame() (int, error) {
 		return 0, err
 	}
 	if line[0] != RespMetaFrame {
-		return 0, fmt.Errorf("skytable: invalid meta frame: %q", line)
+		return 0, protocolError("invalid meta frame: %.100q", line)
 	}
-	return util.Atoi(line[1:])
+	return r.replyLen(line)
 }
 
+// ReadArgs reads a command as written by Writer.WriteArgs and returns its
+// arguments as strings, e.g. to replay captured commands.
+func (r *Reader) ReadArgs() ([]interface{}, error) {
+	line, err := r.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	if line[0] != RespAnyArray {
+		return nil, protocolError("invalid command: %.100q", line)
+	}
+	n, err := r.replyLen(line)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]interface{}, 0, PreallocLen(n))
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		size, err := util.Atoi(line)
+		if err != nil || size < 0 {
+			return nil, protocolError("invalid argument length: %.100q", line)
+		}
+		if err := r.checkLen(size); err != nil {
+			return nil, err
+		}
+		r.sawReply(size + 1)
+		b := make([]byte, size+1)
+		if _, err := io.ReadFull(r.rd, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:size]))
+	}
+	return args, nil
+}
+
+// DiscardNext reads the next reply, including every element of an array,
+// and throws it away without decoding it. Status errors are discarded
+// like any other reply.
+func (r *Reader) DiscardNext() error {
+	line, err := r.ReadLine()
+	if err != nil {
+		return err
+	}
+
+	switch line[0] {
+	case RespStatus, RespInt, RespFloat, RespString, RespBlob:
+		return r.discardPayload(line)
+	case RespArray, RespFlatArray:
+		n, err := r.replyLen(line)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := r.DiscardNext(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case RespAnyArray:
+		n, err := r.replyLen(line)
+		if err != nil {
+			return err
+		}
+		return r.discardElements(n)
+	case RespTypedArray, RespTypedNonNullArray:
+		// The line holds the element type, the count follows it.
+		line, err := r.readLine()
+		if err != nil {
+			return err
+		}
+		n, err := util.Atoi(line)
+		if err != nil {
+			return err
+		}
+		return r.discardElements(n)
+	}
+	return protocolError("can't parse %.100q", line)
+}
+
+// discardPayload skips the payload of a scalar reply whose header is line.
+func (r *Reader) discardPayload(line []byte) error {
+	n, err := r.replyLen(line)
+	if err != nil {
+		return err
+	}
+	_, err = r.rd.Discard(n + 1)
+	return err
+}
+
+// discardElements skips n elements of an array that carries its element
+// type in its header: each element is a length line followed by the
+// payload, or a lone NUL line for a null element.
+func (r *Reader) discardElements(n int) error {
+	for i := 0; i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return err
+		}
+		if len(line) == 1 && line[0] == 0 {
+			continue
+		}
+		size, err := util.Atoi(line)
+		if err != nil {
+			return err
+		}
+		if _, err := r.rd.Discard(size + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadBytes reads a string or blob reply into a new buffer owned by the caller.
 func (r *Reader) ReadBytes() ([]byte, error) {
 	line, err := r.ReadLine()
 	if err != nil {
@@ -355,10 +881,10 @@ func (r *Reader) ReadBytes() ([]byte, error) {
 		if _, err := r.readStatus(line); err != nil {
 			return nil, err
 		}
-	case RespBlob:
-		return r.readLine()
+	case RespString, RespBlob:
+		return r.readBytes(line)
 	}
-	return nil, fmt.Errorf("skytable: can't parse %.100q", line)
+	return nil, typeMismatch(RespBlob, line)
 }
 
 func (r *Reader) ReadArrayLen() (int, error) {
@@ -368,11 +894,11 @@ func (r *Reader) ReadArrayLen() (int, error) {
 	}
 	switch line[0] {
 	case RespArray:
-		return replyLen(line)
+		return r.replyLen(line)
 	case RespStatus:
 		if _, err := r.readStatus(line); err != nil {
 			return 0, err
 		}
 	}
-	return 0, fmt.Errorf("redis: can't parse array reply: %.100q", line)
+	return 0, typeMismatch(RespArray, line)
 }
@@ -2,7 +2,12 @@ package proto_test
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"math"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/satvik007/skytable-go/internal/proto"
@@ -54,6 +59,30 @@ func TestReader_ReadLine(t *testing.T) {
 	}
 }
 
+func TestReader_ReadInt(t *testing.T) {
+	r := proto.NewReader(bytes.NewBufferString(":2\n10\n"))
+	n, err := r.ReadInt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("got %d, wanted 10", n)
+	}
+}
+
+func TestReader_ReadFloat(t *testing.T) {
+	a, b := 0.1, 0.2
+	want := a + b
+	r := proto.NewReader(bytes.NewBufferString("%19\n0.30000000000000004\n"))
+	f, err := r.ReadFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Float64bits(f) != math.Float64bits(want) {
+		t.Errorf("got %v, wanted %v", f, want)
+	}
+}
+
 func benchmarkParseReply(b *testing.B, reply string, wanterr bool) {
 	buf := new(bytes.Buffer)
 	for i := 0; i < b.N; i++ {
@@ -69,3 +98,348 @@ func benchmarkParseReply(b *testing.B, reply string, wanterr bool) {
 		}
 	}
 }
+
+func TestReader_ReadReply_CreateErrors(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  error
+	}{
+		{"err-already-exists", proto.AlreadyExistsError},
+		{"bad-container-name", proto.BadContainerNameError},
+		{"container-name-too-long", proto.ContainerNameTooLongError},
+		{"too-many-args", proto.TooManyArgsError},
+		{"unknown-property", proto.UnknownPropertyError},
+		{"unknown-model", proto.UnknownModelError},
+	}
+	for _, tt := range tests {
+		r := proto.NewReader(bytes.NewBufferString(fmt.Sprintf("!%d\n%s\n", len(tt.reply), tt.reply)))
+		if _, err := r.ReadReply(); err != tt.want {
+			t.Errorf("%s: got %v, wanted %v", tt.reply, err, tt.want)
+		}
+	}
+}
+
+func TestReader_ReadReply_ErrorMessage(t *testing.T) {
+	r := proto.NewReader(bytes.NewBufferString("!20\nSYNTAX invalid syntax\n"))
+	_, err := r.ReadReply()
+	if _, ok := err.(proto.SkytableError); !ok {
+		t.Fatalf("got %T, wanted proto.SkytableError", err)
+	}
+	if err.Error() != "SYNTAX invalid syntax" {
+		t.Errorf("got %q, wanted %q", err, "SYNTAX invalid syntax")
+	}
+}
+
+func TestReader_DiscardNext(t *testing.T) {
+	replies := []string{
+		"!1\n0\n",
+		"!14\nUnknown action\n",
+		":2\n10\n",
+		"%4\n1.25\n",
+		"+5\nhello\n",
+		"?5\nab\ncd\n",
+		"&2\n+5\nhello\n&1\n:1\n1\n",
+		"_2\n+1\na\n:1\n1\n",
+		"@+\n3\n5\nhello\n\x00\n0\n\n",
+		"^?\n1\n2\n\n\n\n",
+	}
+	for _, reply := range replies {
+		r := proto.NewReader(bytes.NewBufferString(reply + "+4\nnext\n"))
+		if err := r.DiscardNext(); err != nil {
+			t.Fatalf("%q: %v", reply, err)
+		}
+		s, err := r.ReadString()
+		if err != nil || s != "next" {
+			t.Fatalf("%q: got %q, %v after discarding, wanted %q", reply, s, err, "next")
+		}
+	}
+}
+
+func TestReader_Attrs(t *testing.T) {
+	r := proto.NewReader(bytes.NewBufferString(
+		"|2\n+4\nnode\n+2\nn1\n+3\nttl\n:2\n60\n+5\nhello\n" +
+			"&2\n+1\na\n|1\n+1\nk\n+1\nv\n+1\nb\n"))
+
+	typ, err := r.PeekReplyType()
+	if err != nil || typ != proto.RespString {
+		t.Fatalf("got %q, %v, wanted %q", typ, err, proto.RespString)
+	}
+	s, err := r.ReadString()
+	if err != nil || s != "hello" {
+		t.Fatalf("got %q, %v, wanted %q", s, err, "hello")
+	}
+	attrs := r.TakeAttrs()
+	if len(attrs) != 2 || attrs["node"] != "n1" || attrs["ttl"] != int64(60) {
+		t.Fatalf("got attrs %v", attrs)
+	}
+	if attrs := r.TakeAttrs(); attrs != nil {
+		t.Fatalf("got attrs %v after taking them", attrs)
+	}
+
+	// Attributes may also prefix an array element.
+	vals, err := r.ReadSlice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vals) != 2 || vals[0] != "a" || vals[1] != "b" {
+		t.Fatalf("got %v, wanted [a b]", vals)
+	}
+	if attrs := r.TakeAttrs(); len(attrs) != 1 || attrs["k"] != "v" {
+		t.Fatalf("got attrs %v", attrs)
+	}
+}
+
+func TestReader_ReadSlice_HugeCount(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	r := proto.NewReader(bytes.NewBufferString("&1000000000\n+1\na\n"))
+	if _, err := r.ReadSlice(); err != io.EOF {
+		t.Fatalf("got %v, wanted %v", err, io.EOF)
+	}
+
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 4<<20 {
+		t.Fatalf("allocated %d bytes for a 1-element body", n)
+	}
+}
+
+func TestReader_AdjustBuffer(t *testing.T) {
+	large := strings.Repeat("x", 500)
+	stream := "+1\na\n?500\n" + large + "\n" + strings.Repeat("+1\nb\n", 20)
+	r := proto.NewReaderSize(bytes.NewBufferString(stream), 64, 1024)
+
+	read := func(want string) {
+		t.Helper()
+		got, err := r.ReadString()
+		if err != nil || got != want {
+			t.Fatalf("got %.10q, %v, wanted %.10q", got, err, want)
+		}
+		r.AdjustBuffer()
+	}
+
+	read("a")
+	if n := r.BufferSize(); n != 64 {
+		t.Fatalf("got a %d-byte buffer after a small reply, wanted 64", n)
+	}
+	read(large)
+	if n := r.BufferSize(); n != 512 {
+		t.Fatalf("got a %d-byte buffer after a 500-byte reply, wanted 512", n)
+	}
+	for i := 0; i < 15; i++ {
+		read("b")
+	}
+	if n := r.BufferSize(); n != 512 {
+		t.Fatalf("got a %d-byte buffer after 15 small replies, wanted it kept at 512", n)
+	}
+	read("b")
+	if n := r.BufferSize(); n != 64 {
+		t.Fatalf("got a %d-byte buffer after 16 small replies, wanted 64", n)
+	}
+
+	// The bytes buffered when the buffer was resized are still read.
+	for i := 0; i < 4; i++ {
+		read("b")
+	}
+	if n := r.Consumed(); n != int64(len(stream)) {
+		t.Fatalf("consumed %d bytes, wanted %d", n, len(stream))
+	}
+}
+
+func TestReader_MaxReplySize(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		read  func(r *proto.Reader) error
+	}{
+		{"array", "&1000000000\n+1\na\n", func(r *proto.Reader) error { _, err := r.ReadSlice(); return err }},
+		{"array len", "&1000000000\n", func(r *proto.Reader) error { _, err := r.ReadArrayLen(); return err }},
+		{"string", "+1000000000\na\n", func(r *proto.Reader) error { _, err := r.ReadString(); return err }},
+		{"nested", "&1\n&1000000000\n", func(r *proto.Reader) error { _, err := r.ReadReply(); return err }},
+		{"discarded", "&1000000000\n", func(r *proto.Reader) error { return r.DiscardNext() }},
+	}
+	for _, tt := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		r := proto.NewReader(bytes.NewBufferString(tt.reply))
+		r.SetMaxReplySize(1 << 20)
+		err := tt.read(r)
+
+		runtime.ReadMemStats(&after)
+		var tooLarge *proto.ReplyTooLargeError
+		if !errors.Is(err, proto.ErrReplyTooLarge) || !errors.As(err, &tooLarge) ||
+			tooLarge.Len != 1000000000 || tooLarge.Max != 1<<20 {
+			t.Fatalf("%s: got %v, wanted a ReplyTooLargeError", tt.name, err)
+		}
+		if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+			t.Fatalf("%s: allocated %d bytes", tt.name, n)
+		}
+	}
+
+	r := proto.NewReader(bytes.NewBufferString("&2\n+1\na\n+1\nb\n"))
+	r.SetMaxReplySize(2)
+	if _, err := r.ReadSlice(); err != nil {
+		t.Fatalf("got %v for a reply within the limit", err)
+	}
+}
+
+func TestReader_EmptyLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		read  func(r *proto.Reader) error
+	}{
+		{"ReadLine", "\n", func(r *proto.Reader) error { _, err := r.ReadLine(); return err }},
+		{"ReadReply", "\n", func(r *proto.Reader) error { _, err := r.ReadReply(); return err }},
+		{"ReadInt", "\n", func(r *proto.Reader) error { _, err := r.ReadInt(); return err }},
+		{"ReadFloat", "\n", func(r *proto.Reader) error { _, err := r.ReadFloat(); return err }},
+		{"ReadString", "\n", func(r *proto.Reader) error { _, err := r.ReadString(); return err }},
+		{"ReadBytes", "\n", func(r *proto.Reader) error { _, err := r.ReadBytes(); return err }},
+		{"ReadSlice", "\n", func(r *proto.Reader) error { _, err := r.ReadSlice(); return err }},
+		{"ReadStatus", "\n", func(r *proto.Reader) error { _, err := r.ReadStatus(); return err }},
+		{"ReadArrayLen", "\n", func(r *proto.Reader) error { _, err := r.ReadArrayLen(); return err }},
+		{"ReadMetaFrame", "\n", func(r *proto.Reader) error { _, err := r.ReadMetaFrame(); return err }},
+		{"DiscardNext", "\n", func(r *proto.Reader) error { return r.DiscardNext() }},
+		{"array element", "&1\n\n", func(r *proto.Reader) error { _, err := r.ReadSlice(); return err }},
+	}
+	for _, tt := range tests {
+		err := tt.read(proto.NewReader(bytes.NewBufferString(tt.reply)))
+		if _, ok := err.(proto.ProtocolError); !ok || !strings.Contains(err.Error(), "invalid reply") {
+			t.Errorf("%s: got %v, wanted an invalid reply ProtocolError", tt.name, err)
+		}
+	}
+}
+
+func TestReader_ReplyTypeMismatch(t *testing.T) {
+	replies := map[byte]string{
+		proto.RespString: "+5\nhello\n",
+		proto.RespBlob:   "?5\nhello\n",
+		proto.RespInt:    ":2\n10\n",
+		proto.RespFloat:  "%4\n1.25\n",
+		proto.RespArray:  "&1\n+1\na\n",
+		proto.RespStatus: "!1\n0\n",
+	}
+	reads := []struct {
+		name     string
+		expected byte
+		accepts  string
+		read     func(r *proto.Reader) error
+	}{
+		{"ReadInt", proto.RespInt, ":", func(r *proto.Reader) error { _, err := r.ReadInt(); return err }},
+		{"ReadFloat", proto.RespFloat, "%", func(r *proto.Reader) error { _, err := r.ReadFloat(); return err }},
+		{"ReadString", proto.RespString, "+?", func(r *proto.Reader) error { _, err := r.ReadString(); return err }},
+		{"ReadBytes", proto.RespBlob, "+?", func(r *proto.Reader) error { _, err := r.ReadBytes(); return err }},
+		{"ReadSlice", proto.RespArray, "&", func(r *proto.Reader) error { _, err := r.ReadSlice(); return err }},
+		{"ReadArrayLen", proto.RespArray, "&", func(r *proto.Reader) error { _, err := r.ReadArrayLen(); return err }},
+		{"ReadStatus", proto.RespStatus, "!", func(r *proto.Reader) error { _, err := r.ReadStatus(); return err }},
+	}
+	for _, rd := range reads {
+		for typ, reply := range replies {
+			err := rd.read(proto.NewReader(bytes.NewBufferString(reply)))
+			if bytes.IndexByte([]byte(rd.accepts), typ) >= 0 {
+				if err != nil {
+					t.Errorf("%s of %q: %v", rd.name, reply, err)
+				}
+				continue
+			}
+
+			var mismatch *proto.ReplyTypeMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Errorf("%s of %q: got %v, wanted a ReplyTypeMismatchError", rd.name, reply, err)
+				continue
+			}
+			if mismatch.Expected != rd.expected || mismatch.Actual != typ {
+				t.Errorf("%s of %q: got %q, %q, wanted %q, %q",
+					rd.name, reply, mismatch.Expected, mismatch.Actual, rd.expected, typ)
+			}
+			if !errors.Is(err, proto.ErrReplyTypeMismatch) {
+				t.Errorf("%s of %q: %v is not ErrReplyTypeMismatch", rd.name, reply, err)
+			}
+		}
+	}
+}
+
+func TestParseErrorReply(t *testing.T) {
+	for _, line := range []string{"", "!"} {
+		err := proto.ParseErrorReply([]byte(line))
+		if _, ok := err.(proto.ProtocolError); !ok {
+			t.Errorf("%q: got %v, wanted a ProtocolError", line, err)
+		}
+		if !strings.Contains(err.Error(), "invalid error reply") {
+			t.Errorf("%q: got %q, wanted an invalid error reply error", line, err)
+		}
+	}
+
+	if err := proto.ParseErrorReply([]byte("!x")); err != proto.SkytableError("x") {
+		t.Errorf("got %v, wanted x", err)
+	}
+}
+
+func FuzzReadReply(f *testing.F) {
+	for _, seed := range []string{
+		"!1\n0\n",
+		"!14\nUnknown action\n",
+		":2\n10\n",
+		"%7\n123.456\n",
+		"+5\nhello\n",
+		"?21\nSYNTAX invalid syntax\n",
+		"&2\n+5\nhello\n+5\nworld\n",
+		"*1\n",
+		"|1\n+1\nk\n+1\nv\n+5\nhello\n",
+		"&99999999999\n",
+		"+99999999999\n",
+		"!\n",
+		"",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := proto.NewReader(bytes.NewReader(b))
+		for {
+			if _, err := r.ReadReply(); err != nil && err != proto.Nil {
+				if _, ok := err.(proto.SkytableError); !ok {
+					return
+				}
+			}
+		}
+	})
+}
+
+func TestParseAll(t *testing.T) {
+	vals, err := proto.ParseAll([]byte("*3\n+5\nhello\n:2\n10\n!1\n1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"hello", int64(10), proto.Nil}
+	if fmt.Sprint(vals) != fmt.Sprint(want) {
+		t.Fatalf("got %v, wanted %v", vals, want)
+	}
+
+	if _, err := proto.ParseAll([]byte("+5\nhel")); err == nil {
+		t.Fatalf("got nil error for a truncated reply")
+	}
+}
+
+func FuzzParseAll(f *testing.F) {
+	// The replies of the benchmarks above.
+	for _, seed := range []string{
+		"!1\n0\n",
+		":2\n10\n",
+		"%7\n123.456\n",
+		"!13\nError message\n",
+		"!1\n1\n",
+		"?21\nSYNTAX invalid syntax\n",
+		"+5\nhello\n",
+		"&2\n+5\nhello\n+5\nworld\n",
+		"*2\n+5\nhello\n!1\n0\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		vals, err := proto.ParseAll(b)
+		if err != nil && vals != nil {
+			t.Fatalf("got values %v along with error %v", vals, err)
+		}
+	})
+}
@@ -23,6 +23,8 @@ type Writer struct {
 
 	lenBuf []byte
 	numBuf []byte
+
+	written int64
 }
 
 func NewWriter(wr writer) *Writer {
@@ -34,6 +36,31 @@ func NewWriter(wr writer) *Writer {
 	}
 }
 
+// Written returns the number of bytes written so far.
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+func (w *Writer) Write(b []byte) (int, error) {
+	n, err := w.writer.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *Writer) WriteByte(c byte) error {
+	err := w.writer.WriteByte(c)
+	if err == nil {
+		w.written++
+	}
+	return err
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	n, err := w.writer.WriteString(s)
+	w.written += int64(n)
+	return n, err
+}
+
 func (w *Writer) WriteMetaFrame(n int) error {
 	if err := w.WriteByte(RespMetaFrame); err != nil {
 		return err
@@ -41,6 +68,15 @@ func (w *Writer) WriteMetaFrame(n int) error {
 	return w.writeLen(n)
 }
 
+// RawArg is an argument written verbatim, after its length prefix, without
+// any type inspection or conversion. The caller is responsible for the
+// bytes being valid for the command they are passed to.
+type RawArg []byte
+
+func (r RawArg) String() string {
+	return string(r)
+}
+
 func (w *Writer) WriteArgs(args []interface{}) error {
 	if err := w.WriteByte(RespAnyArray); err != nil {
 		return err
@@ -68,6 +104,8 @@ func (w *Writer) writeLen(n int) error {
 
 func (w *Writer) WriteArg(v interface{}) error {
 	switch v := v.(type) {
+	case RawArg:
+		return w.bytes(v)
 	case nil:
 		return w.string("")
 	case string:
@@ -95,9 +133,9 @@ func (w *Writer) WriteArg(v interface{}) error {
 	case uint64:
 		return w.uint(v)
 	case float32:
-		return w.float(float64(v))
+		return w.float(float64(v), 32)
 	case float64:
-		return w.float(v)
+		return w.float(v, 64)
 	case bool:
 		if v {
 			return w.int(1)
@@ -148,8 +186,11 @@ func (w *Writer) int(n int64) error {
 	return w.bytes(w.numBuf)
 }
 
-func (w *Writer) float(f float64) error {
-	w.numBuf = strconv.AppendFloat(w.numBuf[:0], f, 'f', -1, 64)
+// float writes f in the shortest decimal form that parses back to the exact
+// same value at the given bit size, without an exponent (e.g. 0.1+0.2 is
+// written as 0.30000000000000004 and float32(0.1) as 0.1).
+func (w *Writer) float(f float64, bitSize int) error {
+	w.numBuf = strconv.AppendFloat(w.numBuf[:0], f, 'f', -1, bitSize)
 	return w.bytes(w.numBuf)
 }
 
@@ -52,6 +52,16 @@ var _ = Describe("WriteBuffer", func() {
 			"\n")))
 	})
 
+	It("should write floats without precision loss", func() {
+		a, b := 0.1, 0.2
+		err := wr.WriteArgs([]interface{}{a + b, float32(0.1)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buf.String()).To(Equal("~2\n" +
+			"19\n0.30000000000000004\n" +
+			"3\n0.1\n"))
+	})
+
 	It("should append time", func() {
 		tm := time.Date(2019, 1, 1, 9, 45, 10, 222125, time.UTC)
 		err := wr.WriteArgs([]interface{}{tm})
@@ -73,6 +83,16 @@ var _ = Describe("WriteBuffer", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(buf.String()).To(Equal(fmt.Sprintf("~1\n16\n%s\n", bytes.NewBuffer(ip))))
 	})
+
+	It("should write raw args verbatim", func() {
+		raw := proto.RawArg{0x00, '\n', 0xff, '~', '1'}
+		err := wr.WriteArgs([]interface{}{raw, "x"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buf.Bytes()).To(Equal([]byte("~2\n" +
+			"5\n\x00\n\xff~1\n" +
+			"1\nx\n")))
+	})
 })
 
 type discard struct{}
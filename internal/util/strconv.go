@@ -6,8 +6,23 @@ func Atoi(b []byte) (int, error) {
 	return strconv.Atoi(BytesToString(b))
 }
 
+// ParseInt is strconv.ParseInt for a byte slice. Short non-negative
+// decimal int64s, like most int replies, are parsed without strconv.
 func ParseInt(b []byte, base int, bitSize int) (int64, error) {
-	return strconv.ParseInt(BytesToString(b), base, bitSize)
+	// 18 digits always fit in an int64.
+	if base != 10 || bitSize != 64 || len(b) == 0 || len(b) > 18 {
+		return strconv.ParseInt(BytesToString(b), base, bitSize)
+	}
+	var n int64
+	for _, c := range b {
+		c -= '0'
+		if c > 9 {
+			// A sign or an invalid byte.
+			return strconv.ParseInt(BytesToString(b), base, bitSize)
+		}
+		n = n*10 + int64(c)
+	}
+	return n, nil
 }
 
 func ParseUint(b []byte, base int, bitSize int) (uint64, error) {
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseInt(t *testing.T) {
+	for _, s := range []string{
+		"", "0", "7", "42", "000123", "123456789012345678", "999999999999999999",
+		"1000000000000000000", "9223372036854775807", "9223372036854775808",
+		"-1", "+1", "-9223372036854775808", "1a", "a", " 1", "1 ", "12/", "1:",
+	} {
+		got, gotErr := ParseInt([]byte(s), 10, 64)
+		want, wantErr := strconv.ParseInt(s, 10, 64)
+		if got != want || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("ParseInt(%q) = %d, %v, wanted %d, %v", s, got, gotErr, want, wantErr)
+		}
+	}
+
+	// Other bases and bit sizes go through strconv.
+	if _, err := ParseInt([]byte("300"), 10, 8); err == nil {
+		t.Error("ParseInt(300, 10, 8): got nil error")
+	}
+	if n, err := ParseInt([]byte("ff"), 16, 64); err != nil || n != 255 {
+		t.Errorf("ParseInt(ff, 16, 64) = %d, %v, wanted 255, <nil>", n, err)
+	}
+}
+
+func BenchmarkParseInt(b *testing.B) {
+	for _, s := range []string{"10", "1234567890", "-10", "9223372036854775807"} {
+		buf := []byte(s)
+		b.Run(s, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = ParseInt(buf, 10, 64)
+			}
+		})
+	}
+}
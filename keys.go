@@ -0,0 +1,285 @@
+package skytable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Copy copies the value of srcKey to dstKey in the current table.
+// With overwrite, an existing dstKey is replaced (USET); otherwise the copy
+// fails with OverwriteError if dstKey exists (SET). A missing srcKey fails
+// with Nil.
+//
+// Both commands run on one pinned connection, but not atomically: a write
+// to srcKey between them may or may not be copied.
+func (c *Client) Copy(ctx context.Context, srcKey, dstKey string, overwrite bool) *StatusCmd {
+	conn := c.Conn()
+	defer conn.Close()
+
+	return copyKey(ctx, conn, srcKey, dstKey, overwrite)
+}
+
+func copyKey(ctx context.Context, conn *Conn, srcKey, dstKey string, overwrite bool) *StatusCmd {
+	val, err := conn.Get(ctx, srcKey).Result()
+	if err != nil {
+		cmd := NewStatusCmd(ctx, "GET", srcKey)
+		if err == Nil {
+			cmd.SetVal(1)
+		}
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	if !overwrite {
+		return conn.Set(ctx, dstKey, val)
+	}
+
+	cmd := NewStatusCmd(ctx, "USET", dstKey, val)
+	uset := NewIntCmd(ctx, cmd.Args()...)
+	_ = conn.Process(ctx, uset)
+	cmd.SetErr(uset.Err())
+	return cmd
+}
+
+// Rename moves the value of oldKey to newKey in the current table,
+// replacing newKey if it exists. A missing oldKey fails with Nil.
+//
+// Skytable has no transactions, so Rename is a copy followed by a delete on
+// one pinned connection and is not atomic: other clients may briefly see
+// both keys, and if the delete fails, both keys are left in place and the
+// error is returned.
+func (c *Client) Rename(ctx context.Context, oldKey, newKey string) *StatusCmd {
+	conn := c.Conn()
+	defer conn.Close()
+
+	if oldKey == newKey {
+		cmd := NewStatusCmd(ctx, "EXISTS", oldKey)
+		n, err := conn.Exists(ctx, oldKey).Result()
+		if err == nil && n == 0 {
+			cmd.SetVal(1)
+			err = Nil
+		}
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	cmd := copyKey(ctx, conn, oldKey, newKey, true)
+	if cmd.Err() != nil {
+		return cmd
+	}
+
+	del := NewIntCmd(ctx, "DEL", oldKey)
+	_ = conn.Process(ctx, del)
+	cmd.SetErr(del.Err())
+	return cmd
+}
+
+// LGetOrInit returns the list at key, creating it with the initial
+// elements (LSET) if it doesn't exist.
+//
+// The commands run on one pinned connection, but not atomically. If
+// another client creates the list first, LSET fails with OverwriteError
+// and that client's list is returned instead. The list is read again
+// after it is created, so a concurrent LMOD may be visible in the result.
+func (c *Client) LGetOrInit(ctx context.Context, key string, initial ...interface{}) *StringSliceCmd {
+	conn := c.Conn()
+	defer conn.Close()
+
+	cmd := conn.LGet(ctx, key)
+	if cmd.Err() != Nil {
+		return cmd
+	}
+
+	if err := conn.LSet(ctx, key, initial...).Err(); err != nil && err != OverwriteError {
+		cmd.SetErr(err)
+		return cmd
+	}
+	return conn.LGet(ctx, key)
+}
+
+// AllExist reports whether every one of keys exists in the current table,
+// by comparing the count returned by EXISTS to the number of keys. It is
+// true for no keys, without calling the server.
+func (c *Client) AllExist(ctx context.Context, keys ...string) (bool, error) {
+	if len(keys) == 0 {
+		return true, nil
+	}
+	n, err := c.Exists(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return n == int64(len(keys)), nil
+}
+
+// WriteMode selects how BulkWrite treats existing keys.
+type WriteMode int
+
+const (
+	// CreateOnly sets the keys only if none of them exists (SSET).
+	CreateOnly WriteMode = iota
+	// UpdateOnly updates the keys only if all of them exist (SUPDATE).
+	UpdateOnly
+	// Upsert sets new keys and updates existing ones (USET).
+	Upsert
+)
+
+func (m WriteMode) String() string {
+	switch m {
+	case CreateOnly:
+		return "CreateOnly"
+	case UpdateOnly:
+		return "UpdateOnly"
+	case Upsert:
+		return "Upsert"
+	default:
+		return fmt.Sprintf("WriteMode(%d)", int(m))
+	}
+}
+
+// BulkWrite writes pairs to the current table in one command chosen by
+// mode and returns the number of keys written. CreateOnly and UpdateOnly
+// are all or nothing: if one key exists (CreateOnly) or is missing
+// (UpdateOnly), nothing is written and the command fails with
+// OverwriteError or Nil respectively.
+//
+// pairs must not be empty. The keys are sent in sorted order.
+func (c *Client) BulkWrite(ctx context.Context, mode WriteMode, pairs map[string]interface{}) *IntCmd {
+	var name string
+	switch mode {
+	case CreateOnly:
+		name = "SSET"
+	case UpdateOnly:
+		name = "SUPDATE"
+	case Upsert:
+		name = "USET"
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]interface{}, 0, 1+2*len(keys))
+	args = append(args, name)
+	for _, key := range keys {
+		args = append(args, key, pairs[key])
+	}
+	cmd := NewIntCmd(ctx, args...)
+
+	switch {
+	case name == "":
+		cmd.SetErr(fmt.Errorf("skytable: invalid write mode %s", mode))
+		return cmd
+	case len(pairs) == 0:
+		cmd.SetErr(errors.New("skytable: BulkWrite needs at least one key"))
+		return cmd
+	case mode == Upsert:
+		_ = c.Process(ctx, cmd)
+		return cmd
+	}
+
+	// SSET and SUPDATE reply with a status rather than a count.
+	status := NewStatusCmd(ctx, args...)
+	_ = c.Process(ctx, status)
+	if err := status.Err(); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	cmd.SetVal(int64(len(pairs)))
+	return cmd
+}
+
+// GetOr is like Get, but a missing key gives defaultValue instead of Nil.
+// Other errors are returned as usual.
+func (c *Client) GetOr(ctx context.Context, key, defaultValue string) *StringCmd {
+	cmd := c.Get(ctx, key)
+	if cmd.Err() == Nil {
+		cmd.SetErr(nil)
+		cmd.SetVal(defaultValue)
+	}
+	return cmd
+}
+
+// VerifySet writes value to key with USET, creating or replacing it, then
+// reads it back with GET and returns an error wrapping ErrValueMismatch
+// if the bytes differ, e.g. in storage tests.
+func (c *Client) VerifySet(ctx context.Context, key string, value []byte) error {
+	if err := c.USet(ctx, key, value).Err(); err != nil {
+		return err
+	}
+	got, err := c.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, value) {
+		return fmt.Errorf("%w: key %q: wrote %d bytes, read %d bytes (%.20q)",
+			ErrValueMismatch, key, len(value), len(got), got)
+	}
+	return nil
+}
+
+// GetWithLen returns the value of key along with its length, sending GET
+// and KEYLEN in one pipeline. A missing key is not an error: it is
+// reported with ValueWithLen.Exists set to false.
+func (c *Client) GetWithLen(ctx context.Context, key string) *GetWithLenCmd {
+	cmd := NewGetWithLenCmd(ctx, "GET", key)
+
+	var get *StringCmd
+	var keyLen *IntCmd
+	_, _ = c.Pipelined(ctx, func(pipe Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		keyLen = pipe.KeyLen(ctx, key)
+		return nil
+	})
+
+	for _, err := range []error{get.Err(), keyLen.Err()} {
+		if err != nil && err != Nil {
+			cmd.SetErr(err)
+			return cmd
+		}
+	}
+	// The key may be deleted or created between the two commands:
+	// it only exists if both found it.
+	if get.Err() == Nil || keyLen.Err() == Nil {
+		return cmd
+	}
+
+	cmd.SetVal(ValueWithLen{
+		Value:  get.Val(),
+		Length: keyLen.Val(),
+		Exists: true,
+	})
+	return cmd
+}
+
+// KeyType reports whether key holds a scalar or a list, so callers can
+// choose between Get and LGet without failing with ErrWrongModel.
+//
+// Skytable types values per table, not per key: the answer comes from
+// the description of Options.Table, or of the connection's current table
+// when it is empty, and it is the same for every key, existing or not.
+// Named tables are inspected once and cached, see TableInfo.
+func (c *Client) KeyType(ctx context.Context, key string) *KeyTypeCmd {
+	cmd := NewKeyTypeCmd(ctx, "KEYTYPE", key)
+
+	info, err := c.TableInfo(ctx, c.table())
+	if err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+	if info.Model != "Keymap" {
+		cmd.SetErr(fmt.Errorf("%w: %s tables have no key type", ErrWrongModel, info.Model))
+		return cmd
+	}
+
+	if info.IsList() {
+		cmd.SetVal(KeyKindList)
+	} else {
+		cmd.SetVal(KeyKindScalar)
+	}
+	return cmd
+}
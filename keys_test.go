@@ -0,0 +1,468 @@
+package skytable_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestCopy(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "src", "v1").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rdb.Copy(ctx, "src", "dst", false).Err(); err != nil {
+		t.Fatalf("copy to a new key: %v", err)
+	}
+	if val, _ := kv.Get("dst"); val != "v1" {
+		t.Fatalf("got %q, wanted v1", val)
+	}
+
+	if err := rdb.Update(ctx, "src", "v2").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Copy(ctx, "src", "dst", false).Err(); err != skytable.OverwriteError {
+		t.Fatalf("copy over an existing key: got %v, wanted %v", err, skytable.OverwriteError)
+	}
+	if val, _ := kv.Get("dst"); val != "v1" {
+		t.Fatalf("got %q, wanted v1", val)
+	}
+
+	if err := rdb.Copy(ctx, "src", "dst", true).Err(); err != nil {
+		t.Fatalf("copy over an existing key with overwrite: %v", err)
+	}
+	if val, _ := kv.Get("dst"); val != "v2" {
+		t.Fatalf("got %q, wanted v2", val)
+	}
+
+	if err := rdb.Copy(ctx, "missing", "dst", true).Err(); err != skytable.Nil {
+		t.Fatalf("copy a missing key: got %v, wanted %v", err, skytable.Nil)
+	}
+}
+
+func TestRename(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "old", "value").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Set(ctx, "new", "replaced").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rdb.Rename(ctx, "old", "new").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := kv.Get("new"); val != "value" {
+		t.Fatalf("got %q, wanted value", val)
+	}
+	if _, ok := kv.Get("old"); ok {
+		t.Fatalf("old key was not deleted")
+	}
+
+	if err := rdb.Rename(ctx, "old", "new").Err(); err != skytable.Nil {
+		t.Fatalf("rename a missing key: got %v, wanted %v", err, skytable.Nil)
+	}
+	if val, _ := kv.Get("new"); val != "value" {
+		t.Fatalf("got %q, wanted value", val)
+	}
+}
+
+func TestGetWithLen(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "key", "hello").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := rdb.GetWithLen(ctx, "key").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := skytable.ValueWithLen{Value: "hello", Length: 5, Exists: true}
+	if val != want {
+		t.Fatalf("got %+v, wanted %+v", val, want)
+	}
+
+	val, err = rdb.GetWithLen(ctx, "missing").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != (skytable.ValueWithLen{}) {
+		t.Fatalf("got %+v, wanted a missing key", val)
+	}
+}
+
+func TestLGetOrInit(t *testing.T) {
+	lists := map[string][]string{"old": {"x"}}
+	// raced is created by "another client" between LGET and LSET.
+	raced := false
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "LGET":
+			if args[1] == "raced" && raced {
+				return fakeArray(fakeString("other"))
+			}
+			l, ok := lists[args[1]]
+			if !ok {
+				return fakeStatus(1)
+			}
+			elems := make([]string, len(l))
+			for i, e := range l {
+				elems[i] = fakeString(e)
+			}
+			return fakeArray(elems...)
+		case "LSET":
+			if args[1] == "raced" {
+				raced = true
+			}
+			if _, ok := lists[args[1]]; ok || raced {
+				return fakeStatus(2)
+			}
+			lists[args[1]] = args[2:]
+			return fakeStatus(0)
+		default:
+			return fakeError("unknown-action")
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"new", []string{"a", "b"}},
+		{"old", []string{"x"}},
+		{"raced", []string{"other"}},
+	}
+	for _, tt := range tests {
+		got, err := rdb.LGetOrInit(ctx, tt.key, "a", "b").Result()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got %q, wanted %q", tt.key, got, tt.want)
+		}
+	}
+	if got := lists["old"]; !reflect.DeepEqual(got, []string{"x"}) {
+		t.Fatalf("existing list changed to %q", got)
+	}
+}
+
+func TestKeyType(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch {
+		case args[0] == "USE":
+			return fakeStatus(0)
+		case args[0] == "INSPECT" && args[2] == "ks:lists":
+			return fakeString("Keymap { data: (str,list<str>), volatile: false }")
+		case args[0] == "INSPECT" && args[2] == "ks:scalars":
+			return fakeString("Keymap { data: (binstr,binstr), volatile: true }")
+		default:
+			return fakeError("container-not-found")
+		}
+	})
+
+	tests := []struct {
+		table string
+		want  skytable.KeyKind
+	}{
+		{"ks:lists", skytable.KeyKindList},
+		{"ks:scalars", skytable.KeyKindScalar},
+	}
+	for _, tt := range tests {
+		opt := srv.options()
+		opt.Table = tt.table
+		rdb := skytable.NewClient(opt)
+
+		for i := 0; i < 2; i++ {
+			got, err := rdb.KeyType(ctx, "key").Result()
+			if err != nil {
+				t.Fatalf("%s: %v", tt.table, err)
+			}
+			if got != tt.want {
+				t.Fatalf("%s: got %s, wanted %s", tt.table, got, tt.want)
+			}
+		}
+		_ = rdb.Close()
+	}
+
+	var inspects int
+	for _, args := range srv.Commands() {
+		if args[0] == "INSPECT" {
+			inspects++
+		}
+	}
+	if inspects != 2 {
+		t.Fatalf("got %d INSPECT TABLE, wanted 1 per table", inspects)
+	}
+}
+
+func TestBulkWrite(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	pairs := map[string]interface{}{"a": "1", "b": "2"}
+	if n, err := rdb.BulkWrite(ctx, skytable.CreateOnly, pairs).Result(); err != nil || n != 2 {
+		t.Fatalf("CreateOnly: got %d, %v, wanted 2", n, err)
+	}
+
+	// CreateOnly is all or nothing: c isn't created because b exists.
+	err := rdb.BulkWrite(ctx, skytable.CreateOnly, map[string]interface{}{"b": "x", "c": "3"}).Err()
+	if err != skytable.OverwriteError {
+		t.Fatalf("CreateOnly over b: got %v, wanted %v", err, skytable.OverwriteError)
+	}
+	if _, ok := kv.Get("c"); ok {
+		t.Fatalf("c was created by a failed CreateOnly")
+	}
+
+	if n, err := rdb.BulkWrite(ctx, skytable.UpdateOnly, map[string]interface{}{"a": "10"}).Result(); err != nil || n != 1 {
+		t.Fatalf("UpdateOnly: got %d, %v, wanted 1", n, err)
+	}
+	err = rdb.BulkWrite(ctx, skytable.UpdateOnly, map[string]interface{}{"a": "x", "c": "3"}).Err()
+	if err != skytable.Nil {
+		t.Fatalf("UpdateOnly of c: got %v, wanted %v", err, skytable.Nil)
+	}
+
+	if n, err := rdb.BulkWrite(ctx, skytable.Upsert, map[string]interface{}{"b": "20", "c": "30"}).Result(); err != nil || n != 2 {
+		t.Fatalf("Upsert: got %d, %v, wanted 2", n, err)
+	}
+	for key, want := range map[string]string{"a": "10", "b": "20", "c": "30"} {
+		if val, _ := kv.Get(key); val != want {
+			t.Fatalf("%s: got %q, wanted %q", key, val, want)
+		}
+	}
+
+	n := len(srv.Commands())
+	if err := rdb.BulkWrite(ctx, skytable.Upsert, nil).Err(); err == nil {
+		t.Fatalf("got nil error for no pairs")
+	}
+	if err := rdb.BulkWrite(ctx, skytable.WriteMode(42), pairs).Err(); err == nil {
+		t.Fatalf("got nil error for an invalid mode")
+	}
+	if len(srv.Commands()) != n {
+		t.Fatalf("invalid BulkWrite calls were sent")
+	}
+}
+
+func TestAllExist(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.BulkWrite(ctx, skytable.Upsert, map[string]interface{}{"a": "1", "b": "2"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keys []string
+		want bool
+	}{
+		{[]string{"a", "b"}, true},
+		{[]string{"a", "missing", "b"}, false},
+		{nil, true},
+	}
+	for _, tt := range tests {
+		got, err := rdb.AllExist(ctx, tt.keys...)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.keys, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%q: got %v, wanted %v", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestGetOr(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(func(args []string) string {
+		if args[0] == "GET" && args[1] == "list" {
+			return fakeError("wrong-model")
+		}
+		return kv.handle(args)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "a", "1").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+		err  error
+	}{
+		{"a", "1", nil},
+		{"missing", "default", nil},
+		{"list", "", skytable.ErrWrongModel},
+	}
+	for _, tt := range tests {
+		got, err := rdb.GetOr(ctx, tt.key, "default").Result()
+		if err != tt.err {
+			t.Fatalf("%s: got error %v, wanted %v", tt.key, err, tt.err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: got %q, wanted %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestVerifySet(t *testing.T) {
+	kv := newFakeKeymap()
+	var corrupt bool
+	srv := newFakeServer(func(args []string) string {
+		if args[0] == "GET" && corrupt {
+			v, _ := kv.Get(args[1])
+			return fakeString(v[:len(v)-1] + "?")
+		}
+		return kv.handle(args)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	value := []byte(strings.Repeat("abc", 1000))
+	for i := 0; i < 2; i++ {
+		if err := rdb.VerifySet(ctx, "big", value); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+
+	corrupt = true
+	if err := rdb.VerifySet(ctx, "big", value); !errors.Is(err, skytable.ErrValueMismatch) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrValueMismatch)
+	}
+}
+
+func TestSetMapCommands(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.SSetMap(ctx, map[string]interface{}{"b": "2", "a": 1}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.Commands()[0]; !reflect.DeepEqual(got, []string{"SSET", "a", "1", "b", "2"}) {
+		t.Fatalf("got %q, wanted the pairs in key order", got)
+	}
+	if err := rdb.SSetMap(ctx, map[string]interface{}{"a": "x", "c": "3"}).Err(); err != skytable.OverwriteError {
+		t.Fatalf("SSetMap on an existing key: got %v, wanted OverwriteError", err)
+	}
+
+	if err := rdb.SUpdateMap(ctx, map[string]interface{}{"a": "10", "b": "20"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := kv.Get("b"); val != "20" {
+		t.Fatalf("got b=%q after SUpdateMap, wanted 20", val)
+	}
+	if err := rdb.SUpdateMap(ctx, map[string]interface{}{"a": "x", "c": "3"}).Err(); err != skytable.Nil {
+		t.Fatalf("SUpdateMap on a missing key: got %v, wanted Nil", err)
+	}
+
+	if err := rdb.SDelKeys(ctx, []string{"a", "c"}).Err(); err != skytable.Nil {
+		t.Fatalf("SDelKeys on a missing key: got %v, wanted Nil", err)
+	}
+	if err := rdb.SDelKeys(ctx, []string{"a", "b"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv.Get("a"); ok {
+		t.Fatal("a still exists after SDelKeys")
+	}
+
+	sent := len(srv.Commands())
+	for name, err := range map[string]error{
+		"SSetMap(nil)":       rdb.SSetMap(ctx, nil).Err(),
+		"SUpdateMap(empty)":  rdb.SUpdateMap(ctx, map[string]interface{}{}).Err(),
+		"SSetMap(empty key)": rdb.SSetMap(ctx, map[string]interface{}{"": "1"}).Err(),
+		"SDelKeys(nil)":      rdb.SDelKeys(ctx, nil).Err(),
+		"SDelKeys(empty)":    rdb.SDelKeys(ctx, []string{"a", ""}).Err(),
+	} {
+		if err == nil {
+			t.Errorf("%s: got nil error", name)
+		}
+	}
+	if n := len(srv.Commands()); n != sent {
+		t.Fatalf("got %d commands sent for invalid input, wanted none", n-sent)
+	}
+}
+
+func TestEncodeBinaryKeys(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	opt := srv.options()
+	opt.EncodeBinaryKeys = true
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	key := "\x00\xffid"
+	if err := rdb.Set(ctx, key, "v").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv.Get("\x00b64:" + base64.StdEncoding.EncodeToString([]byte(key))); !ok {
+		t.Fatalf("got key %q, wanted %q base64-encoded", srv.Commands()[0][1], key)
+	}
+	if got, err := rdb.Get(ctx, key).Result(); err != nil || got != "v" {
+		t.Fatalf("got %q, %v, wanted v, <nil>", got, err)
+	}
+
+	// Only the keys of key-value pairs are encoded.
+	if err := rdb.BulkWrite(ctx, skytable.Upsert, map[string]interface{}{"k": "\x01"}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := kv.Get("\x00b64:aw=="); val != "\x01" {
+		t.Fatalf("got %q stored under the encoded key, wanted the plain value", val)
+	}
+
+	// Keys written without the option are listed as they are, even if
+	// they are valid base64.
+	for _, plain := range []string{"test", "abcd", "aw=="} {
+		kv.handle([]string{"SET", plain, "v"})
+	}
+
+	keys, err := rdb.LSKeys(ctx, "", 0).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if want := []string{key, "abcd", "aw==", "k", "test"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got keys %q, wanted %q", keys, want)
+	}
+
+	// The arguments of the caller are left untouched.
+	args := []interface{}{"GET", key}
+	_ = rdb.Do(ctx, args...).Err()
+	if args[1] != key {
+		t.Fatalf("got %q, wanted the caller's arguments unchanged", args[1])
+	}
+}
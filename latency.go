@@ -0,0 +1,180 @@
+package skytable
+
+import (
+	"context"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/satvik007/skytable-go/internal"
+)
+
+// Latencies are recorded in log-linear buckets, as in HDR histograms:
+// durations below 2*latencySubBuckets nanoseconds get a bucket each, and
+// every following power of two is split in latencySubBuckets buckets, so a
+// recorded value is off by at most 1/latencySubBuckets (12.5%).
+const (
+	latencySubBucketBits = 3
+	latencySubBuckets    = 1 << latencySubBucketBits
+	latencyBuckets       = (64-latencySubBucketBits)*latencySubBuckets + latencySubBuckets
+)
+
+func latencyBucket(ns uint64) int {
+	if ns < 2*latencySubBuckets {
+		return int(ns)
+	}
+	shift := bits.Len64(ns) - latencySubBucketBits - 1
+	return shift*latencySubBuckets + int(ns>>shift)
+}
+
+// latencyBucketMax returns the largest value recorded in bucket i.
+func latencyBucketMax(i int) uint64 {
+	if i < 2*latencySubBuckets {
+		return uint64(i)
+	}
+	shift := i/latencySubBuckets - 1
+	mant := uint64(i%latencySubBuckets + latencySubBuckets)
+	return (mant+1)<<shift - 1
+}
+
+type latencyHistogram struct {
+	max     uint64
+	buckets [latencyBuckets]uint64
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	ns := uint64(d)
+	atomic.AddUint64(&h.buckets[latencyBucket(ns)], 1)
+	for {
+		max := atomic.LoadUint64(&h.max)
+		if ns <= max || atomic.CompareAndSwapUint64(&h.max, max, ns) {
+			return
+		}
+	}
+}
+
+func (h *latencyHistogram) snapshot() *HistogramSnapshot {
+	s := &HistogramSnapshot{
+		Max:     time.Duration(atomic.LoadUint64(&h.max)),
+		buckets: make([]uint64, latencyBuckets),
+	}
+	// Count is summed from the buckets copied, so that it matches them
+	// even while commands are recorded.
+	for i := range h.buckets {
+		s.buckets[i] = atomic.LoadUint64(&h.buckets[i])
+		s.Count += s.buckets[i]
+	}
+	return s
+}
+
+// HistogramSnapshot is a copy of the latencies recorded for a command,
+// as returned by Client.LatencyHistogram.
+type HistogramSnapshot struct {
+	// Count is the number of commands recorded.
+	Count uint64
+	// Max is the highest latency recorded.
+	Max time.Duration
+
+	buckets []uint64
+}
+
+// Quantile returns the latency below which the fraction q of the
+// commands completed, e.g. 0.99 for the 99th percentile. It is accurate
+// to 12.5% and never above Max. It returns 0 if nothing was recorded.
+func (s *HistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	if q < 0 {
+		q = 0
+	} else if q > 1 {
+		q = 1
+	}
+	rank := uint64(q*float64(s.Count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range s.buckets {
+		seen += n
+		if seen >= rank {
+			if d := time.Duration(latencyBucketMax(i)); d < s.Max {
+				return d
+			}
+			return s.Max
+		}
+	}
+	return s.Max
+}
+
+// latencyRecorder is the hook installed by Options.EnableLatencyHistograms.
+type latencyRecorder struct {
+	mu         sync.RWMutex
+	histograms map[string]*latencyHistogram
+}
+
+type latencyStartKey struct{}
+
+var _ Hook = (*latencyRecorder)(nil)
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{histograms: make(map[string]*latencyHistogram)}
+}
+
+func (r *latencyRecorder) histogram(name string) *latencyHistogram {
+	r.mu.RLock()
+	h := r.histograms[name]
+	r.mu.RUnlock()
+	if h != nil {
+		return h
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h = r.histograms[name]; h == nil {
+		h = new(latencyHistogram)
+		r.histograms[name] = h
+	}
+	return h
+}
+
+func (r *latencyRecorder) BeforeProcess(ctx context.Context, cmd Cmder) (context.Context, error) {
+	return context.WithValue(ctx, latencyStartKey{}, time.Now()), nil
+}
+
+func (r *latencyRecorder) AfterProcess(ctx context.Context, cmd Cmder) error {
+	if start, ok := ctx.Value(latencyStartKey{}).(time.Time); ok {
+		r.histogram(cmd.Name()).record(time.Since(start))
+	}
+	return nil
+}
+
+func (r *latencyRecorder) BeforeProcessPipeline(ctx context.Context, cmds []Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (r *latencyRecorder) AfterProcessPipeline(ctx context.Context, cmds []Cmder) error {
+	return nil
+}
+
+// LatencyHistogram returns the latencies of the commands named name, e.g.
+// "get", sent with Process since the client was created. Commands sent in
+// pipelines are not recorded. It returns nil if
+// Options.EnableLatencyHistograms is not set or no such command was sent.
+func (c *Client) LatencyHistogram(name string) *HistogramSnapshot {
+	if c.latency == nil {
+		return nil
+	}
+	c.latency.mu.RLock()
+	h := c.latency.histograms[internal.ToLower(name)]
+	c.latency.mu.RUnlock()
+	if h == nil {
+		return nil
+	}
+	return h.snapshot()
+}
@@ -0,0 +1,61 @@
+package skytable_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(func(args []string) string {
+		if args[0] == "GET" && args[1] == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return kv.handle(args)
+	})
+
+	opt := srv.options()
+	opt.EnableLatencyHistograms = true
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if h := rdb.LatencyHistogram("get"); h != nil {
+		t.Fatalf("got %+v before any GET, wanted nil", h)
+	}
+
+	for i := 0; i < 99; i++ {
+		_ = rdb.Get(ctx, "fast").Err()
+	}
+	_ = rdb.Get(ctx, "slow").Err()
+
+	h := rdb.LatencyHistogram("GET")
+	if h == nil {
+		t.Fatal("got nil histogram")
+	}
+	if h.Count != 100 {
+		t.Fatalf("got Count %d, wanted 100", h.Count)
+	}
+	p50, p99, p100 := h.Quantile(0.5), h.Quantile(0.99), h.Quantile(1)
+	if p50 <= 0 || p50 >= 50*time.Millisecond {
+		t.Fatalf("got p50 %s, wanted it below the slow GET", p50)
+	}
+	if p99 < p50 || p99 >= 50*time.Millisecond {
+		t.Fatalf("got p99 %s, wanted it between p50 and the slow GET", p99)
+	}
+	if p100 != h.Max || h.Max < 50*time.Millisecond {
+		t.Fatalf("got p100 %s and Max %s, wanted the slow GET", p100, h.Max)
+	}
+
+	if rdb.LatencyHistogram("set") != nil {
+		t.Fatal("got a histogram for a command never sent")
+	}
+
+	plain := skytable.NewClient(srv.options())
+	defer plain.Close()
+	_ = plain.Get(ctx, "fast").Err()
+	if plain.LatencyHistogram("get") != nil {
+		t.Fatal("got a histogram without EnableLatencyHistograms")
+	}
+}
@@ -1,14 +1,18 @@
 package skytable_test
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -241,6 +245,291 @@ func startSkytable(port string, args ...string) (*skytableProcess, error) {
 
 // ------------------------------------------------------------------------------
 
+// fakeServer is an in-memory Skyhash server for tests that don't need skyd.
+// Every received command is passed to handler, which returns the raw reply.
+// An empty reply leaves the whole frame unanswered.
+type fakeServer struct {
+	// newHandler returns the handler of a new connection.
+	newHandler func() func(args []string) string
+
+	mu       sync.Mutex
+	dials    int
+	commands [][]string
+}
+
+func newFakeServer(handler func(args []string) string) *fakeServer {
+	return newFakeServerPerConn(func() func(args []string) string {
+		return handler
+	})
+}
+
+// newFakeServerPerConn is like newFakeServer, but every connection gets its
+// own handler from newHandler, e.g. to track per-connection state.
+func newFakeServerPerConn(newHandler func() func(args []string) string) *fakeServer {
+	return &fakeServer{newHandler: newHandler}
+}
+
+func (s *fakeServer) options() *skytable.Options {
+	return &skytable.Options{
+		Dialer:      s.dial,
+		ReadTimeout: time.Second,
+		MaxRetries:  -1,
+	}
+}
+
+func (s *fakeServer) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, server := net.Pipe()
+
+	s.mu.Lock()
+	s.dials++
+	s.mu.Unlock()
+
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *fakeServer) Dials() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dials
+}
+
+func (s *fakeServer) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...)
+}
+
+func (s *fakeServer) serve(cn net.Conn) {
+	defer cn.Close()
+
+	handler := s.newHandler()
+	rd := bufio.NewReader(cn)
+	for {
+		n, err := readFakeLen(rd, '*')
+		if err != nil {
+			return
+		}
+
+		var b strings.Builder
+		b.WriteString("*" + strconv.Itoa(n) + "\n")
+		silent := false
+		for i := 0; i < n; i++ {
+			args, err := readFakeArgs(rd)
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.commands = append(s.commands, args)
+			s.mu.Unlock()
+
+			reply := handler(args)
+			if reply == fakeHangUp {
+				return
+			}
+			if reply == "" {
+				silent = true
+			}
+			b.WriteString(reply)
+		}
+
+		if silent {
+			continue
+		}
+		if _, err := io.WriteString(cn, b.String()); err != nil {
+			return
+		}
+	}
+}
+
+// fakeHangUp makes the fakeServer close the connection instead of replying.
+const fakeHangUp = "\x00hang up"
+
+func fakeStatus(code int) string {
+	s := strconv.Itoa(code)
+	return "!" + strconv.Itoa(len(s)) + "\n" + s + "\n"
+}
+
+func fakeError(msg string) string {
+	return "!" + strconv.Itoa(len(msg)) + "\n" + msg + "\n"
+}
+
+func fakeString(s string) string {
+	return "+" + strconv.Itoa(len(s)) + "\n" + s + "\n"
+}
+
+func fakeBlob(b string) string {
+	return "?" + strconv.Itoa(len(b)) + "\n" + b + "\n"
+}
+
+func fakeInt(n int) string {
+	s := strconv.Itoa(n)
+	return ":" + strconv.Itoa(len(s)) + "\n" + s + "\n"
+}
+
+func fakeArray(elems ...string) string {
+	return "&" + strconv.Itoa(len(elems)) + "\n" + strings.Join(elems, "")
+}
+
+// fakeKeymap is a fakeServer handler backed by an in-memory keymap table.
+type fakeKeymap struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func newFakeKeymap() *fakeKeymap {
+	return &fakeKeymap{m: make(map[string]string)}
+}
+
+func (kv *fakeKeymap) Get(key string) (string, bool) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	val, ok := kv.m[key]
+	return val, ok
+}
+
+func (kv *fakeKeymap) handle(args []string) string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	switch args[0] {
+	case "GET":
+		val, ok := kv.m[args[1]]
+		if !ok {
+			return fakeStatus(1)
+		}
+		return fakeString(val)
+	case "SET":
+		if _, ok := kv.m[args[1]]; ok {
+			return fakeStatus(2)
+		}
+		kv.m[args[1]] = args[2]
+		return fakeStatus(0)
+	case "KEYLEN":
+		val, ok := kv.m[args[1]]
+		if !ok {
+			return fakeStatus(1)
+		}
+		return fakeInt(len(val))
+	case "UPDATE":
+		if _, ok := kv.m[args[1]]; !ok {
+			return fakeStatus(1)
+		}
+		kv.m[args[1]] = args[2]
+		return fakeStatus(0)
+	case "SSET", "SUPDATE":
+		// All or nothing: SSET needs every key to be new, SUPDATE every
+		// key to exist.
+		for i := 1; i+1 < len(args); i += 2 {
+			if _, ok := kv.m[args[i]]; ok != (args[0] == "SUPDATE") {
+				if ok {
+					return fakeStatus(2)
+				}
+				return fakeStatus(1)
+			}
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			kv.m[args[i]] = args[i+1]
+		}
+		return fakeStatus(0)
+	case "USET":
+		for i := 1; i+1 < len(args); i += 2 {
+			kv.m[args[i]] = args[i+1]
+		}
+		return fakeInt((len(args) - 1) / 2)
+	case "EXISTS":
+		var n int
+		for _, key := range args[1:] {
+			if _, ok := kv.m[key]; ok {
+				n++
+			}
+		}
+		return fakeInt(n)
+	case "DEL":
+		var n int
+		for _, key := range args[1:] {
+			if _, ok := kv.m[key]; ok {
+				delete(kv.m, key)
+				n++
+			}
+		}
+		return fakeInt(n)
+	case "DBSIZE":
+		return fakeInt(len(kv.m))
+	case "LSKEYS":
+		keys := make([]string, 0, len(kv.m))
+		for key := range kv.m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		elems := make([]string, len(keys))
+		for i, key := range keys {
+			elems[i] = fakeString(key)
+		}
+		return fakeArray(elems...)
+	case "MGET":
+		elems := make([]string, 0, len(args)-1)
+		for _, key := range args[1:] {
+			if val, ok := kv.m[key]; ok {
+				elems = append(elems, fakeString(val))
+			} else {
+				elems = append(elems, fakeStatus(1))
+			}
+		}
+		return fakeArray(elems...)
+	case "SDEL":
+		for _, key := range args[1:] {
+			if _, ok := kv.m[key]; !ok {
+				return fakeStatus(1)
+			}
+		}
+		for _, key := range args[1:] {
+			delete(kv.m, key)
+		}
+		return fakeStatus(0)
+	default:
+		return fakeError("Unknown action")
+	}
+}
+
+func readFakeLen(rd *bufio.Reader, prefix byte) (int, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+	return strconv.Atoi(line[1 : len(line)-1])
+}
+
+func readFakeArgs(rd *bufio.Reader) ([]string, error) {
+	n, err := readFakeLen(rd, '~')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(line[:len(line)-1])
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+1)
+		if _, err := io.ReadFull(rd, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+// ------------------------------------------------------------------------------
+
 type badConnError string
 
 func (e badConnError) Error() string   { return string(e) }
@@ -252,6 +541,8 @@ type badConn struct {
 
 	readDelay, writeDelay time.Duration
 	readErr, writeErr     error
+
+	writeDeadline time.Time
 }
 
 var _ net.Conn = &badConn{}
@@ -261,6 +552,7 @@ func (cn *badConn) SetReadDeadline(t time.Time) error {
 }
 
 func (cn *badConn) SetWriteDeadline(t time.Time) error {
+	cn.writeDeadline = t
 	return nil
 }
 
@@ -276,6 +568,10 @@ func (cn *badConn) Read([]byte) (int, error) {
 
 func (cn *badConn) Write([]byte) (int, error) {
 	if cn.writeDelay != 0 {
+		if !cn.writeDeadline.IsZero() && time.Until(cn.writeDeadline) < cn.writeDelay {
+			time.Sleep(time.Until(cn.writeDeadline))
+			return 0, badConnError("i/o timeout")
+		}
 		time.Sleep(cn.writeDelay)
 	}
 	if cn.writeErr != nil {
@@ -0,0 +1,130 @@
+package skytable
+
+import (
+	"context"
+	"strings"
+)
+
+// migrateBatchSize is the number of keys read with one MGET by MigrateTable.
+const migrateBatchSize = 100
+
+// MigrateResult reports what MigrateTable did.
+type MigrateResult struct {
+	// Copied is the number of keys written to the destination table.
+	Copied int64
+	// Skipped is the number of keys that already existed in the destination
+	// table, e.g. because they were copied by an earlier, interrupted run.
+	Skipped int64
+	// Errors holds the keys that couldn't be written with the reason,
+	// e.g. a binstr value that is not valid UTF-8 for a str table.
+	Errors map[string]error
+}
+
+// fullEntity returns table in the FQE syntax, prefixing it with the default
+// keyspace like Options.Table.
+func fullEntity(table string) string {
+	if !strings.Contains(table, ":") {
+		return "default:" + table
+	}
+	return table
+}
+
+// MigrateTable copies every key of the src table to the dst table,
+// creating dst as keymap(str,binstr) if it doesn't exist yet. To migrate
+// to another model, e.g. from binstr back to str values, create dst first.
+//
+// Values are copied as-is. Keys that already exist in dst are left
+// untouched and counted as skipped, so an interrupted migration can be
+// resumed by running it again. Keys that can't be written are reported in
+// MigrateResult.Errors and don't stop the migration.
+//
+// The migration is not transactional: keys written to src while it runs
+// may or may not be copied, and src is left unchanged. With
+// Options.EmulateExpiry, the keys SetEx uses to emulate expiry are not
+// copied, so keys of src do not expire in dst.
+func (c *Client) MigrateTable(ctx context.Context, src, dst string) (*MigrateResult, error) {
+	src, dst = fullEntity(src), fullEntity(dst)
+	res := &MigrateResult{Errors: make(map[string]error)}
+
+	err := c.withScratchConn(ctx, func(from *Conn) error {
+		return c.withScratchConn(ctx, func(to *Conn) error {
+			err := to.CreateTable(ctx, dst, "keymap", []string{"str", "binstr"}).Err()
+			if err != nil && err != AlreadyExistsError {
+				return err
+			}
+			if err := to.Use(ctx, dst).Err(); err != nil {
+				return err
+			}
+
+			keys, err := exportKeys(ctx, from, src)
+			if err != nil {
+				return err
+			}
+			if err := from.Use(ctx, src).Err(); err != nil {
+				return err
+			}
+
+			for len(keys) > 0 {
+				n := migrateBatchSize
+				if n > len(keys) {
+					n = len(keys)
+				}
+				if err := importBatch(ctx, from, to, keys[:n], res); err != nil {
+					return err
+				}
+				keys = keys[n:]
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// exportKeys lists all the keys of table, but the ones SetEx uses to
+// emulate expiry if LSKeys leaves them out.
+func exportKeys(ctx context.Context, conn *Conn, table string) ([]string, error) {
+	// Conn.DbSize counts them, as the limit of LSKEYS has to.
+	n, err := conn.DbSize(ctx, table).Result()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return conn.LSKeys(ctx, table, int(n)).Result()
+}
+
+// importBatch reads keys on from and writes them on to with SET.
+func importBatch(ctx context.Context, from, to *Conn, keys []string, res *MigrateResult) error {
+	vals, err := from.MGet(ctx, keys).Result()
+	if err != nil {
+		return err
+	}
+
+	for i, val := range vals {
+		switch val := val.(type) {
+		case nil:
+			// Deleted since the keys were listed.
+			continue
+		case error:
+			res.Errors[keys[i]] = val
+			continue
+		}
+
+		err := to.Set(ctx, keys[i], val).Err()
+		switch {
+		case err == nil:
+			res.Copied++
+		case err == OverwriteError:
+			res.Skipped++
+		case isSkytableError(err):
+			res.Errors[keys[i]] = err
+		default:
+			return err
+		}
+	}
+	return nil
+}
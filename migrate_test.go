@@ -0,0 +1,44 @@
+package skytable_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/satvik007/skytable-go"
+)
+
+var _ = Describe("MigrateTable", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(skytableOptions())
+		Expect(client.FlushDB(ctx, "").Err()).NotTo(HaveOccurred())
+		_ = client.DropTable(ctx, "default:migrated").Err()
+	})
+
+	AfterEach(func() {
+		Expect(client.DropTable(ctx, "default:migrated").Err()).NotTo(HaveOccurred())
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should migrate a table", func() {
+		Expect(client.MSet(ctx, "k1", "v1", "k2", "v2", "k3", "v3").Err()).NotTo(HaveOccurred())
+
+		res, err := client.MigrateTable(ctx, "test15", "migrated")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Copied).To(Equal(int64(3)))
+		Expect(res.Skipped).To(BeZero())
+		Expect(res.Errors).To(BeEmpty())
+
+		// Resuming skips the keys that were already copied.
+		Expect(client.Set(ctx, "k4", "v4").Err()).NotTo(HaveOccurred())
+		res, err = client.MigrateTable(ctx, "test15", "migrated")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Copied).To(Equal(int64(1)))
+		Expect(res.Skipped).To(Equal(int64(3)))
+
+		Expect(client.DbSize(ctx, "default:migrated").Val()).To(Equal(int64(4)))
+		// The client's own table is untouched.
+		Expect(client.Get(ctx, "k1").Val()).To(Equal("v1"))
+	})
+})
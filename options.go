@@ -3,11 +3,14 @@ package skytable
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/satvik007/skytable-go/internal"
 	"github.com/satvik007/skytable-go/internal/pool"
 )
 
@@ -35,8 +38,17 @@ type Options struct {
 	// Network and Addr options.
 	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
 
+	// Transport, if set, replaces the connection pool: every query is
+	// encoded and handed to it, and the response it returns is decoded.
+	// See Transport.
+	Transport Transport
+
 	// Hook that is called when new connection is established.
 	OnConnect func(ctx context.Context, cn *Conn) error
+	// InitCommands run in order on every new connection, after the login
+	// and the USE of Table and before OnConnect. The first error aborts
+	// the connection init and is returned to the command that needed it.
+	InitCommands []func(ctx context.Context, cn *Conn) error
 
 	// Optional Username. Required only when authn is enabled in the configuration.
 	// Use the specified Username to authenticate the current connection.
@@ -59,6 +71,23 @@ type Options struct {
 	// When you connect to Skytable, you are connected to the default keyspace which has a default table.
 	Table string
 
+	// AutoSelectContainer makes a command that fails with
+	// ErrDefaultContainerUnset select Table again with USE and run once more
+	// on the same connection. It has no effect when Table is empty.
+	AutoSelectContainer bool
+
+	// AutoCreateOnMissing, when set, is called when a command on keys of
+	// Table fails with ContainerNotFoundError, e.g. because the table was
+	// dropped, with Table as entity. If it returns nil, the client selects
+	// Table again and resends the command once; its error is returned
+	// otherwise. DDL, INSPECT and commands on another entity never call
+	// it, nor do clients without Table, so a misspelled entity still fails.
+	//
+	// It must create the table with a client of its own that doesn't set
+	// Table: connections of this client fail to initialize while the
+	// table is missing. It runs while the failed connection is held.
+	AutoCreateOnMissing func(ctx context.Context, entity string) error
+
 	// Maximum number of retries before giving up.
 	// Default is 3 retries; -1 (not 0) disables retries.
 	MaxRetries int
@@ -72,6 +101,12 @@ type Options struct {
 	// Dial timeout for establishing new connections.
 	// Default is 5 seconds.
 	DialTimeout time.Duration
+	// Maximum number of times a failed dial is retried, with the retry
+	// backoff, before the command that needed the connection fails, e.g.
+	// while the server is still starting. Unlike MaxRetries, it applies
+	// before any command is sent.
+	// Default is 0: dial failures are not retried.
+	DialRetries int
 	// Timeout for socket reads. If reached, commands will fail
 	// with a timeout instead of blocking. Use value -1 for no timeout and 0 for default.
 	// Default is 3 seconds.
@@ -80,6 +115,10 @@ type Options struct {
 	// with a timeout instead of blocking.
 	// Default is ReadTimeout.
 	WriteTimeout time.Duration
+	// Timeout for reading the reply of FlushDB, which takes time
+	// proportional to the number of keys flushed. Use value -1 for no timeout.
+	// Default is ReadTimeout.
+	FlushDBTimeout time.Duration
 
 	// Type of connection pool.
 	// true for FIFO pool, false for LIFO pool.
@@ -89,11 +128,20 @@ type Options struct {
 	// Default is 10 connections per every available CPU as reported by runtime.GOMAXPROCS.
 	PoolSize int
 	// Minimum number of idle connections which is useful when establishing
-	// new connection is slow.
+	// new connection is slow. Values above PoolSize are lowered to PoolSize.
 	MinIdleConns int
 	// Connection age at which client retires (closes) the connection.
 	// Default is to not close aged connections.
 	MaxConnAge time.Duration
+	// MaxConnAgeJitter lowers the MaxConnAge of each connection by a random
+	// duration between 0 and MaxConnAgeJitter, so connections dialed
+	// together are not all retired, and redialed, at the same time.
+	// It can't exceed MaxConnAge.
+	MaxConnAgeJitter time.Duration
+	// Number of commands after which client retires (closes) the connection.
+	// A pipeline counts as a single command.
+	// Default is to not close connections based on their use.
+	MaxConnUses int
 	// Amount of time client waits for connection if all connections
 	// are busy before returning an error.
 	// Default is ReadTimeout + 1 second.
@@ -107,14 +155,173 @@ type Options struct {
 	// but idle connections are still discarded by the client
 	// if IdleTimeout is set.
 	IdleCheckFrequency time.Duration
+	// IdleReapLimit is the maximum number of stale idle connections closed
+	// by each idle check; the others are left for the next checks.
+	// Default is 0, which closes all of them at once.
+	IdleReapLimit int
+	// IdleReapStrategy selects which stale idle connections are closed
+	// when there are more than IdleReapLimit: LongestIdle (the default)
+	// or OldestCreated, e.g. to rotate connections after a DNS change.
+	IdleReapStrategy IdleReapStrategy
+
+	// ReadBufferSize is the size of the read buffer of each connection.
+	// It grows, doubling, up to MaxReadBufferSize for replies that don't
+	// fit, and shrinks back once the connection reads small replies again,
+	// so a rare large reply doesn't keep the memory in use.
+	// Default is 4 KiB for ReadBufferSize and 64 KiB for MaxReadBufferSize;
+	// set MaxReadBufferSize to ReadBufferSize to never grow the buffer.
+	ReadBufferSize    int
+	MaxReadBufferSize int
+	// MaxReplySize is the largest length, in bytes, or element count, for
+	// arrays, a reply may announce. Larger replies fail with
+	// ErrReplyTooLarge before anything is allocated for them, so a corrupt
+	// or hostile length can't exhaust memory.
+	// Default is 512 MiB; -1 disables the limit.
+	MaxReplySize int
+
+	// ReadOnly makes the client fail commands that change data, schema or
+	// users with ErrReadOnly before sending them, e.g. for audit tools.
+	ReadOnly bool
+	// AllowedCommands, when not empty, lists the only commands the client
+	// may send, and DeniedCommands lists commands it may not send, e.g.
+	// "flushdb" or "drop". Commands are matched by Cmder.FullName, ignoring
+	// case. Rejected commands fail with ErrCommandNotAllowed.
+	AllowedCommands []string
+	DeniedCommands  []string
+
+	// EncodeBinaryKeys makes the client base64-encode the keys it sends,
+	// so that keys holding any bytes can be used with a str key type,
+	// and decode the keys listed by LSKEYS.
+	//
+	// Keys are stored encoded, behind a NUL byte and "b64:": other clients,
+	// and this one without the option, see the encoded form and don't find
+	// keys by their plain name. LSKEYS only decodes the keys starting with
+	// that prefix, so keys written without the option are returned as they
+	// are. Encoding makes keys a third longer.
+	EncodeBinaryKeys bool
+
+	// EmulateExpiry enables Client.SetEx and Client.GetEx, which emulate
+	// key expiry with companion keys, see SetEx. LSKEYS then leaves the
+	// companion keys out, so it may return fewer keys than its limit,
+	// and Client.DbSize subtracts them, which lists the keys of the
+	// current table once SetEx was used on it.
+	EmulateExpiry bool
+
+	// VerifyProtocol makes the client check the protocol version of the
+	// server, with SYS INFO protover, on every new connection, and fail
+	// it with ErrUnsupportedProtocol if the client doesn't speak it.
+	// Servers that reject SYS INFO are not checked.
+	VerifyProtocol bool
+
+	// FrameWriter, when set, writes the frame that starts every request in
+	// place of the Skyhash meta frame, given the number of commands that
+	// follow: 1 for a single command, or the length of a pipeline. It may
+	// write anything before or instead of the meta frame, e.g. to talk
+	// through a proxy or try protocol extensions, with
+	// ProtoWriter.WriteMetaFrame, Write or WriteString.
+	FrameWriter func(wr *ProtoWriter, count int) error
+
+	// EnableLatencyHistograms makes the client record the latency of
+	// every command by command name, see Client.LatencyHistogram.
+	EnableLatencyHistograms bool
 
 	// TLS Config to use. When set TLS will be negotiated.
 	TLSConfig *tls.Config
+	// TLSInsecureSkipVerify negotiates TLS without verifying the server
+	// certificate when TLSConfig is nil, e.g. for a local skyd with a
+	// self-signed certificate.
+	//
+	// WARNING: this makes the connection open to man-in-the-middle attacks.
+	// Never enable it in production; use TLSConfig with the server's CA
+	// in RootCAs instead.
+	TLSInsecureSkipVerify bool
 
 	// Limiter interface used to implemented circuit breaker or rate limiter.
 	Limiter Limiter
 }
 
+// Validate reports the first setting that can't work, before any default
+// is applied. NewClientWithError calls it; NewClient does not.
+func (opt *Options) Validate() error {
+	if opt.Addr == "" && opt.Dialer == nil && opt.Transport == nil {
+		return errors.New("skytable: Addr is required when neither Dialer nor Transport is set")
+	}
+	if opt.PoolSize < 0 {
+		return fmt.Errorf("skytable: invalid PoolSize %d", opt.PoolSize)
+	}
+	if opt.MinIdleConns < 0 {
+		return fmt.Errorf("skytable: invalid MinIdleConns %d", opt.MinIdleConns)
+	}
+	if opt.PoolSize > 0 && opt.MinIdleConns > opt.PoolSize {
+		return fmt.Errorf("skytable: MinIdleConns %d exceeds PoolSize %d",
+			opt.MinIdleConns, opt.PoolSize)
+	}
+	if opt.MaxRetries < -1 {
+		return fmt.Errorf("skytable: invalid MaxRetries %d", opt.MaxRetries)
+	}
+
+	// -1 is the only negative duration with a meaning: it disables the setting.
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"ReadTimeout", opt.ReadTimeout},
+		{"WriteTimeout", opt.WriteTimeout},
+		{"FlushDBTimeout", opt.FlushDBTimeout},
+		{"MinRetryBackoff", opt.MinRetryBackoff},
+		{"MaxRetryBackoff", opt.MaxRetryBackoff},
+		{"IdleTimeout", opt.IdleTimeout},
+		{"IdleCheckFrequency", opt.IdleCheckFrequency},
+	}
+	for _, d := range durations {
+		if d.d < -1 {
+			return fmt.Errorf("skytable: invalid %s %s (use -1 to disable it)", d.name, d.d)
+		}
+	}
+	if opt.DialTimeout < 0 {
+		return fmt.Errorf("skytable: invalid DialTimeout %s", opt.DialTimeout)
+	}
+	if opt.PoolTimeout < 0 {
+		return fmt.Errorf("skytable: invalid PoolTimeout %s", opt.PoolTimeout)
+	}
+	if opt.MaxConnAge < 0 {
+		return fmt.Errorf("skytable: invalid MaxConnAge %s", opt.MaxConnAge)
+	}
+	if opt.MaxConnAgeJitter < 0 || opt.MaxConnAgeJitter > opt.MaxConnAge {
+		return fmt.Errorf("skytable: invalid MaxConnAgeJitter %s for MaxConnAge %s",
+			opt.MaxConnAgeJitter, opt.MaxConnAge)
+	}
+	if opt.MaxConnUses < 0 {
+		return fmt.Errorf("skytable: invalid MaxConnUses %d", opt.MaxConnUses)
+	}
+	if opt.ReadBufferSize < 0 {
+		return fmt.Errorf("skytable: invalid ReadBufferSize %d", opt.ReadBufferSize)
+	}
+	if opt.MaxReadBufferSize < 0 ||
+		opt.MaxReadBufferSize > 0 && opt.MaxReadBufferSize < opt.ReadBufferSize {
+		return fmt.Errorf("skytable: invalid MaxReadBufferSize %d for ReadBufferSize %d",
+			opt.MaxReadBufferSize, opt.ReadBufferSize)
+	}
+	if opt.MaxReplySize < -1 {
+		return fmt.Errorf("skytable: invalid MaxReplySize %d (use -1 to disable it)", opt.MaxReplySize)
+	}
+	if opt.DialRetries < 0 {
+		return fmt.Errorf("skytable: invalid DialRetries %d", opt.DialRetries)
+	}
+	if opt.IdleReapLimit < 0 {
+		return fmt.Errorf("skytable: invalid IdleReapLimit %d", opt.IdleReapLimit)
+	}
+	if opt.IdleReapStrategy != LongestIdle && opt.IdleReapStrategy != OldestCreated {
+		return fmt.Errorf("skytable: invalid IdleReapStrategy %d", opt.IdleReapStrategy)
+	}
+	if opt.MinRetryBackoff > 0 && opt.MaxRetryBackoff > 0 &&
+		opt.MinRetryBackoff > opt.MaxRetryBackoff {
+		return fmt.Errorf("skytable: MinRetryBackoff %s exceeds MaxRetryBackoff %s",
+			opt.MinRetryBackoff, opt.MaxRetryBackoff)
+	}
+	return nil
+}
+
 func (opt *Options) init() {
 	if opt.Addr == "" {
 		opt.Addr = "localhost:2003"
@@ -128,6 +335,11 @@ func (opt *Options) init() {
 	if opt.DialTimeout == 0 {
 		opt.DialTimeout = 5 * time.Second
 	}
+	if opt.TLSConfig == nil && opt.TLSInsecureSkipVerify {
+		internal.Logger.Printf(context.Background(),
+			"TLSInsecureSkipVerify is set: the server certificate is not verified, do not use it in production")
+		opt.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
 	if opt.Dialer == nil {
 		opt.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
 			netDialer := &net.Dialer{
@@ -143,6 +355,12 @@ func (opt *Options) init() {
 	if opt.PoolSize == 0 {
 		opt.PoolSize = 10 * runtime.GOMAXPROCS(0)
 	}
+	if opt.MinIdleConns > opt.PoolSize {
+		internal.Logger.Printf(context.Background(),
+			"MinIdleConns %d exceeds PoolSize %d, using %d",
+			opt.MinIdleConns, opt.PoolSize, opt.PoolSize)
+		opt.MinIdleConns = opt.PoolSize
+	}
 	switch opt.ReadTimeout {
 	case -1:
 		opt.ReadTimeout = 0
@@ -155,6 +373,12 @@ func (opt *Options) init() {
 	case 0:
 		opt.WriteTimeout = opt.ReadTimeout
 	}
+	switch opt.FlushDBTimeout {
+	case -1:
+		opt.FlushDBTimeout = 0
+	case 0:
+		opt.FlushDBTimeout = opt.ReadTimeout
+	}
 	if opt.PoolTimeout == 0 {
 		opt.PoolTimeout = opt.ReadTimeout + time.Second
 	}
@@ -164,6 +388,21 @@ func (opt *Options) init() {
 	if opt.IdleCheckFrequency == 0 {
 		opt.IdleCheckFrequency = time.Minute
 	}
+	if opt.ReadBufferSize == 0 {
+		opt.ReadBufferSize = 4 << 10
+	}
+	if opt.MaxReadBufferSize == 0 {
+		opt.MaxReadBufferSize = 64 << 10
+	}
+	if opt.MaxReadBufferSize < opt.ReadBufferSize {
+		opt.MaxReadBufferSize = opt.ReadBufferSize
+	}
+	switch opt.MaxReplySize {
+	case -1:
+		opt.MaxReplySize = 0
+	case 0:
+		opt.MaxReplySize = 512 << 20
+	}
 
 	if opt.MaxRetries == -1 {
 		opt.MaxRetries = 0
@@ -192,14 +431,28 @@ func (opt *Options) clone() *Options {
 func newConnPool(opt *Options) *pool.ConnPool {
 	return pool.NewConnPool(&pool.Options{
 		Dialer: func(ctx context.Context) (net.Conn, error) {
-			return opt.Dialer(ctx, opt.Network, opt.Addr)
+			cn, err := opt.Dialer(ctx, opt.Network, opt.Addr)
+			for attempt := 1; err != nil && attempt <= opt.DialRetries; attempt++ {
+				backoff := internal.RetryBackoff(attempt, opt.MinRetryBackoff, opt.MaxRetryBackoff)
+				if err := internal.Sleep(ctx, backoff); err != nil {
+					return nil, err
+				}
+				cn, err = opt.Dialer(ctx, opt.Network, opt.Addr)
+			}
+			return cn, err
 		},
 		PoolFIFO:           opt.PoolFIFO,
 		PoolSize:           opt.PoolSize,
 		MinIdleConns:       opt.MinIdleConns,
 		MaxConnAge:         opt.MaxConnAge,
+		MaxConnAgeJitter:   opt.MaxConnAgeJitter,
 		PoolTimeout:        opt.PoolTimeout,
 		IdleTimeout:        opt.IdleTimeout,
 		IdleCheckFrequency: opt.IdleCheckFrequency,
+		IdleReapLimit:      opt.IdleReapLimit,
+		IdleReapStrategy:   opt.IdleReapStrategy,
+		ReadBufferSize:     opt.ReadBufferSize,
+		MaxReadBufferSize:  opt.MaxReadBufferSize,
+		MaxReplySize:       opt.MaxReplySize,
 	})
 }
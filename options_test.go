@@ -0,0 +1,303 @@
+package skytable_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  skytable.Options
+		err  string
+	}{
+		{"empty Addr", skytable.Options{}, "Addr is required"},
+		{"negative PoolSize", skytable.Options{Addr: skytableAddr, PoolSize: -1}, "invalid PoolSize"},
+		{"negative MinIdleConns", skytable.Options{Addr: skytableAddr, MinIdleConns: -1}, "invalid MinIdleConns"},
+		{
+			"MinIdleConns above PoolSize",
+			skytable.Options{Addr: skytableAddr, PoolSize: 2, MinIdleConns: 3},
+			"MinIdleConns 3 exceeds PoolSize 2",
+		},
+		{"MaxRetries", skytable.Options{Addr: skytableAddr, MaxRetries: -2}, "invalid MaxRetries"},
+		{"ReadTimeout", skytable.Options{Addr: skytableAddr, ReadTimeout: -time.Second}, "invalid ReadTimeout"},
+		{"WriteTimeout", skytable.Options{Addr: skytableAddr, WriteTimeout: -2}, "invalid WriteTimeout"},
+		{"DialTimeout", skytable.Options{Addr: skytableAddr, DialTimeout: -1}, "invalid DialTimeout"},
+		{"PoolTimeout", skytable.Options{Addr: skytableAddr, PoolTimeout: -1}, "invalid PoolTimeout"},
+		{"MaxConnAge", skytable.Options{Addr: skytableAddr, MaxConnAge: -1}, "invalid MaxConnAge"},
+		{"MaxConnAgeJitter", skytable.Options{Addr: skytableAddr, MaxConnAge: time.Minute, MaxConnAgeJitter: time.Hour}, "invalid MaxConnAgeJitter"},
+		{"MaxConnUses", skytable.Options{Addr: skytableAddr, MaxConnUses: -1}, "invalid MaxConnUses"},
+		{"IdleReapLimit", skytable.Options{Addr: skytableAddr, IdleReapLimit: -1}, "invalid IdleReapLimit"},
+		{"IdleReapStrategy", skytable.Options{Addr: skytableAddr, IdleReapStrategy: 7}, "invalid IdleReapStrategy"},
+		{"MaxReadBufferSize", skytable.Options{Addr: skytableAddr, ReadBufferSize: 8192, MaxReadBufferSize: 4096}, "invalid MaxReadBufferSize"},
+		{"MaxReplySize", skytable.Options{Addr: skytableAddr, MaxReplySize: -2}, "invalid MaxReplySize"},
+		{"DialRetries", skytable.Options{Addr: skytableAddr, DialRetries: -1}, "invalid DialRetries"},
+		{
+			"retry backoff",
+			skytable.Options{Addr: skytableAddr, MinRetryBackoff: time.Second, MaxRetryBackoff: time.Millisecond},
+			"MinRetryBackoff 1s exceeds MaxRetryBackoff 1ms",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opt.Validate()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("got %v, wanted an error containing %q", err, test.err)
+			}
+
+			client, err := skytable.NewClientWithError(&test.opt)
+			if err == nil || client != nil {
+				t.Fatalf("NewClientWithError: got %v, %v, wanted an error", client, err)
+			}
+		})
+	}
+}
+
+func TestOptionsValidateDefaults(t *testing.T) {
+	opt := &skytable.Options{
+		Addr:         skytableAddr,
+		ReadTimeout:  -1,
+		WriteTimeout: -1,
+		MaxRetries:   -1,
+	}
+	if err := opt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := skytable.NewClientWithError(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMinIdleConnsClampedToPoolSize(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	opt := srv.options()
+	opt.PoolSize = 2
+	opt.MinIdleConns = 5
+
+	done := make(chan *skytable.Client)
+	go func() {
+		done <- skytable.NewClient(opt)
+	}()
+
+	var client *skytable.Client
+	select {
+	case client = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("NewClient hangs")
+	}
+	defer client.Close()
+
+	if opt.MinIdleConns != opt.PoolSize {
+		t.Fatalf("got MinIdleConns %d, wanted %d", opt.MinIdleConns, opt.PoolSize)
+	}
+	if err := client.Heya(ctx, "").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for srv.Dials() < opt.PoolSize && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := client.PoolStats()
+	if stats.TotalConns > uint32(opt.PoolSize) || stats.IdleConns > uint32(opt.PoolSize) {
+		t.Fatalf("got %d conns and %d idle conns, wanted at most %d",
+			stats.TotalConns, stats.IdleConns, opt.PoolSize)
+	}
+}
+
+func TestMaxConnUses(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	opt := srv.options()
+	opt.PoolSize = 1
+	opt.MaxConnUses = 3
+
+	client := skytable.NewClient(opt)
+	defer client.Close()
+
+	for i := 0; i < 7; i++ {
+		if err := client.Heya(ctx, "").Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Dials(); n != 3 {
+		t.Fatalf("got %d dials, wanted 3", n)
+	}
+}
+
+func TestRefreshCredentials(t *testing.T) {
+	srv := newFakeServerPerConn(func() func(args []string) string {
+		var user string
+		return func(args []string) string {
+			switch args[0] {
+			case "AUTH":
+				user = args[1]
+				return fakeStatus(0)
+			default:
+				time.Sleep(10 * time.Millisecond)
+				return fakeString(user)
+			}
+		}
+	})
+
+	var mu sync.Mutex
+	user := "old"
+	opt := srv.options()
+	opt.PoolSize = 3
+	opt.CredentialsProvider = func() (string, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		return user, "token"
+	}
+
+	client := skytable.NewClient(opt)
+	defer client.Close()
+
+	heya := func(want string) {
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := client.Heya(ctx, "").Result()
+				if err != nil {
+					t.Error(err)
+				} else if got != want {
+					t.Errorf("got a connection authenticated as %q, wanted %q", got, want)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	heya("old")
+	dials := srv.Dials()
+
+	mu.Lock()
+	user = "new"
+	mu.Unlock()
+	client.RefreshCredentials()
+
+	for i := 0; i < 3; i++ {
+		heya("new")
+	}
+	if n := srv.Dials(); n <= dials {
+		t.Fatalf("got %d dials, wanted more than %d", n, dials)
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	client := skytable.NewClient(&skytable.Options{
+		Addr:                  skytableAddr,
+		TLSInsecureSkipVerify: true,
+	})
+	defer client.Close()
+
+	cfg := client.Options().TLSConfig
+	if cfg == nil || !cfg.InsecureSkipVerify {
+		t.Fatalf("got %+v, wanted a TLSConfig with InsecureSkipVerify", cfg)
+	}
+}
+
+func TestFlushDBTimeout(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[0] == "FLUSHDB" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return fakeStatus(0)
+	})
+
+	opt := srv.options()
+	opt.ReadTimeout = 100 * time.Millisecond
+	opt.FlushDBTimeout = time.Second
+	client := skytable.NewClient(opt)
+	defer client.Close()
+
+	if err := client.FlushDB(ctx, "").Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushDBTimeoutNotRetried(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		time.Sleep(200 * time.Millisecond)
+		return fakeStatus(0)
+	})
+
+	opt := srv.options()
+	opt.MaxRetries = 2
+	opt.FlushDBTimeout = 50 * time.Millisecond
+	client := skytable.NewClient(opt)
+	defer client.Close()
+
+	if err := client.FlushDB(ctx, "").Err(); err == nil {
+		t.Fatal("got nil, wanted a timeout error")
+	}
+	if n := len(srv.Commands()); n != 1 {
+		t.Fatalf("got %d FLUSHDB commands, wanted 1", n)
+	}
+}
+
+func TestPoolMode(t *testing.T) {
+	for _, fifo := range []bool{false, true} {
+		client := skytable.NewClient(&skytable.Options{Addr: skytableAddr, PoolFIFO: fifo})
+		want := "lifo"
+		if fifo {
+			want = "fifo"
+		}
+		if got := client.PoolMode(); got != want {
+			t.Errorf("PoolFIFO=%v: PoolMode() = %q, want %q", fifo, got, want)
+		}
+		_ = client.Close()
+	}
+}
+
+func TestDialRetries(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+
+	for _, retries := range []int{0, 2} {
+		var dials int
+		opt := srv.options()
+		dial := opt.Dialer
+		opt.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			if dials < 3 {
+				return nil, errors.New("connection refused")
+			}
+			return dial(ctx, network, addr)
+		}
+		opt.DialRetries = retries
+		opt.MaxRetries = -1
+
+		client := skytable.NewClient(opt)
+		err := client.Heya(ctx, "").Err()
+		_ = client.Close()
+
+		if retries == 0 {
+			if err == nil || dials != 1 {
+				t.Fatalf("DialRetries 0: got %v after %d dials, wanted an error after 1", err, dials)
+			}
+			continue
+		}
+		if err != nil || dials != 3 {
+			t.Fatalf("DialRetries %d: got %v after %d dials, wanted success after 3", retries, err, dials)
+		}
+	}
+}
@@ -1,12 +1,18 @@
 package skytable
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"sync"
+
+	"github.com/satvik007/skytable-go/internal/proto"
 )
 
 type pipelineExecer func(context.Context, []Cmder) error
 
+type pipelineEncoder func([]Cmder) ([]byte, error)
+
 // Pipeliner is a mechanism to realise Skytable Pipeline technique.
 //
 // Pipelining is a technique to extremely speed up processing by packing
@@ -26,7 +32,10 @@ type Pipeliner interface {
 	Do(ctx context.Context, args ...interface{}) *Cmd
 	Process(ctx context.Context, cmd Cmder) error
 	Discard()
+	DiscardAndReturn() []Cmder
+	Encode() ([]byte, error)
 	Exec(ctx context.Context) ([]Cmder, error)
+	ExecAndReset(ctx context.Context) ([]Cmder, error)
 }
 
 var _ Pipeliner = (*Pipeline)(nil)
@@ -38,7 +47,8 @@ type Pipeline struct {
 	cmdable
 	statefulCmdable
 
-	exec pipelineExecer
+	exec   pipelineExecer
+	encode pipelineEncoder
 
 	mu   sync.Mutex
 	cmds []Cmder
@@ -79,11 +89,35 @@ func (c *Pipeline) Discard() {
 	c.mu.Unlock()
 }
 
+// DiscardAndReturn resets the pipeline like Discard and returns the
+// discarded commands, e.g. to log or queue them again.
+func (c *Pipeline) DiscardAndReturn() []Cmder {
+	c.mu.Lock()
+	cmds := c.cmds
+	c.cmds = nil
+	c.mu.Unlock()
+	return cmds
+}
+
+// Encode returns the bytes Exec would send for the queued commands,
+// without sending them or emptying the pipeline. Client.ReplayEncoded
+// sends them again, e.g. to reproduce a failing pipeline.
+//
+// The client options apply as with Exec: keys are rewritten by
+// Options.EncodeBinaryKeys, the frame is written by Options.FrameWriter,
+// and commands the client may not send make Encode fail.
+func (c *Pipeline) Encode() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.encode(c.cmds)
+}
+
 // Exec executes all previously queued commands using one
 // client-server roundtrip.
 //
 // Exec always returns list of commands and error of the first failed
-// command if any.
+// command if any. The pipeline is empty afterwards and can be reused.
 func (c *Pipeline) Exec(ctx context.Context) ([]Cmder, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -98,6 +132,13 @@ func (c *Pipeline) Exec(ctx context.Context) ([]Cmder, error) {
 	return cmds, c.exec(ctx, cmds)
 }
 
+// ExecAndReset is Exec, named for code that queues and executes batches
+// on the same pipeline in a loop. Commands queued after it returns go
+// into the next batch, whether or not the previous one failed.
+func (c *Pipeline) ExecAndReset(ctx context.Context) ([]Cmder, error) {
+	return c.Exec(ctx)
+}
+
 func (c *Pipeline) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
 	if err := fn(c); err != nil {
 		return nil, err
@@ -108,3 +149,54 @@ func (c *Pipeline) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]C
 func (c *Pipeline) Pipeline() Pipeliner {
 	return c
 }
+
+// encodeCmds returns the bytes processPipeline writes for cmds, without
+// counting them as sent.
+func (c *baseClient) encodeCmds(cmds []Cmder) ([]byte, error) {
+	for _, cmd := range cmds {
+		if err := c.checkCmd(cmd); err != nil {
+			return nil, err
+		}
+		c.prepareCmd(cmd)
+	}
+
+	var buf bytes.Buffer
+	wr := proto.NewWriter(&buf)
+	if err := c.writeMetaFrame(wr, len(cmds)); err != nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		if err := wr.WriteArgs(cmd.Args()); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ReplayEncoded sends the commands in data, as returned by
+// Pipeline.Encode, in one pipeline. They are returned as *Cmd in the order
+// they were encoded, with the error of the first failed command if any.
+func (c *Client) ReplayEncoded(ctx context.Context, data []byte) ([]Cmder, error) {
+	rd := proto.NewReader(bytes.NewReader(data))
+	n, err := rd.ReadMetaFrame()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]Cmder, 0, proto.PreallocLen(n))
+	for i := 0; i < n; i++ {
+		args, err := rd.ReadArgs()
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, NewCmd(ctx, args...))
+	}
+	if _, err := rd.PeekReplyType(); err != io.EOF {
+		return nil, ProtocolError("skytable: trailing data after encoded pipeline")
+	}
+
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	return cmds, c.processPipeline(ctx, cmds)
+}
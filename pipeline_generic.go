@@ -0,0 +1,37 @@
+//go:build go1.18
+
+package skytable
+
+import "context"
+
+// TypedCmd is implemented by the commands whose Result returns a T,
+// e.g. *StringCmd is a TypedCmd[string] and *IntCmd a TypedCmd[int64].
+type TypedCmd[T any] interface {
+	Cmder
+	Result() (T, error)
+}
+
+// ExecTuple2 executes pipe and returns the values of a and b, which must
+// have been queued on pipe. The error is the one returned by Exec.
+//
+//	pipe := rdb.Pipeline()
+//	name, size, err := skytable.ExecTuple2(ctx, pipe,
+//		pipe.Get(ctx, "name"),
+//		pipe.DbSize(ctx, ""))
+func ExecTuple2[A, B any](ctx context.Context, pipe Pipeliner, a TypedCmd[A], b TypedCmd[B]) (A, B, error) {
+	_, err := pipe.Exec(ctx)
+	av, _ := a.Result()
+	bv, _ := b.Result()
+	return av, bv, err
+}
+
+// ExecTuple3 is like ExecTuple2 for three commands.
+func ExecTuple3[A, B, C any](
+	ctx context.Context, pipe Pipeliner, a TypedCmd[A], b TypedCmd[B], c TypedCmd[C],
+) (A, B, C, error) {
+	_, err := pipe.Exec(ctx)
+	av, _ := a.Result()
+	bv, _ := b.Result()
+	cv, _ := c.Result()
+	return av, bv, cv, err
+}
@@ -0,0 +1,44 @@
+//go:build go1.18
+
+package skytable_test
+
+import (
+	"testing"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestExecTuple(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "GET":
+			return fakeString("value")
+		case "DBSIZE":
+			return fakeInt(42)
+		default:
+			return fakeStatus(1)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	pipe := rdb.Pipeline()
+	val, size, err := skytable.ExecTuple2(ctx, pipe, pipe.Get(ctx, "key"), pipe.DbSize(ctx, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "value" || size != 42 {
+		t.Fatalf("got %q, %d, wanted value, 42", val, size)
+	}
+
+	pipe = rdb.Pipeline()
+	val, size, missing, err := skytable.ExecTuple3(ctx, pipe,
+		pipe.Get(ctx, "key"), pipe.DbSize(ctx, ""), pipe.LGetFirst(ctx, "list"))
+	if err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+	if val != "value" || size != 42 || missing != "" {
+		t.Fatalf("got %q, %d, %q", val, size, missing)
+	}
+}
@@ -0,0 +1,241 @@
+package skytable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestPipelineDiscardAndReturn(t *testing.T) {
+	rdb := skytable.NewClient(&skytable.Options{Addr: skytableAddr})
+	defer rdb.Close()
+
+	pipe := rdb.Pipeline()
+	want := []skytable.Cmder{
+		pipe.Get(ctx, "a"),
+		pipe.Set(ctx, "b", "1"),
+		pipe.Del(ctx, "c"),
+	}
+
+	got := pipe.DiscardAndReturn()
+	if len(got) != len(want) {
+		t.Fatalf("got %d commands, wanted %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("command %d: got %v, wanted %v", i, got[i], want[i])
+		}
+	}
+	if n := pipe.Len(); n != 0 {
+		t.Fatalf("got Len %d after DiscardAndReturn, wanted 0", n)
+	}
+}
+
+func TestPipelineExecAndReset(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	pipe := rdb.Pipeline()
+	for i, key := range []string{"a", "b"} {
+		pipe.Set(ctx, key, key)
+		get := pipe.Get(ctx, key)
+
+		cmds, err := pipe.ExecAndReset(ctx)
+		if err != nil {
+			t.Fatalf("cycle %d: %v", i, err)
+		}
+		if len(cmds) != 2 {
+			t.Fatalf("cycle %d: got %d commands, wanted 2", i, len(cmds))
+		}
+		if get.Val() != key {
+			t.Fatalf("cycle %d: got %q, wanted %q", i, get.Val(), key)
+		}
+		if n := pipe.Len(); n != 0 {
+			t.Fatalf("cycle %d: got Len %d after ExecAndReset, wanted 0", i, n)
+		}
+	}
+	if n := len(srv.Commands()); n != 4 {
+		t.Fatalf("server got %d commands, wanted 4", n)
+	}
+}
+
+func TestPipelinedChunked(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var batches []int
+	rdb.AddHook(&hook{
+		afterProcessPipeline: func(ctx context.Context, cmds []skytable.Cmder) error {
+			batches = append(batches, len(cmds))
+			return nil
+		},
+	})
+
+	cmds, err := rdb.PipelinedChunked(ctx, 3, func(pipe skytable.Pipeliner) error {
+		for i := 0; i < 10; i++ {
+			pipe.Set(ctx, fmt.Sprint("key", i), fmt.Sprint(i))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 10 {
+		t.Fatalf("got %d commands, wanted 10", len(cmds))
+	}
+	for i, cmd := range cmds {
+		if key := cmd.Args()[1]; key != fmt.Sprint("key", i) {
+			t.Fatalf("command %d: got key %v, wanted key%d", i, key, i)
+		}
+	}
+	if !reflect.DeepEqual(batches, []int{3, 3, 3, 1}) {
+		t.Fatalf("got batches %v, wanted [3 3 3 1]", batches)
+	}
+	if _, ok := kv.Get("key9"); !ok {
+		t.Fatalf("key9 wasn't set")
+	}
+}
+
+func TestPipelineEncodeReplay(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	pipe := rdb.Pipeline()
+	pipe.Set(ctx, "a", "1")
+	pipe.Get(ctx, "a")
+	pipe.Do(ctx, "SET", "b", "")
+
+	data, err := pipe.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "*3\n" +
+		"~3\n3\nSET\n1\na\n1\n1\n" +
+		"~2\n3\nGET\n1\na\n" +
+		"~3\n3\nSET\n1\nb\n0\n\n"
+	if string(data) != want {
+		t.Fatalf("got %q, wanted %q", data, want)
+	}
+	if n := pipe.Len(); n != 3 {
+		t.Fatalf("got Len %d after Encode, wanted 3", n)
+	}
+	pipe.Discard()
+
+	cmds, err := rdb.ReplayEncoded(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, wanted 3", len(cmds))
+	}
+	if got := cmds[1].(*skytable.Cmd).Val(); got != "1" {
+		t.Fatalf("GET: got %v, wanted 1", got)
+	}
+	if !reflect.DeepEqual(srv.Commands()[0], []string{"SET", "a", "1"}) {
+		t.Fatalf("got %q, wanted the replayed SET", srv.Commands()[0])
+	}
+
+	for _, bad := range [][]byte{data[:len(data)-2], append(append([]byte(nil), data...), '~'), []byte("~1\n")} {
+		if _, err := rdb.ReplayEncoded(ctx, bad); err == nil {
+			t.Errorf("%q: got nil error", bad)
+		}
+	}
+}
+
+func TestPipelineEncodeOptions(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	var counts []int
+	opt := srv.options()
+	opt.EncodeBinaryKeys = true
+	opt.FrameWriter = func(wr *skytable.ProtoWriter, count int) error {
+		counts = append(counts, count)
+		return wr.WriteMetaFrame(count)
+	}
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	pipe := rdb.Pipeline()
+	pipe.Set(ctx, "a", "1")
+	data, err := pipe.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*1\n~3\n3\nSET\n9\n\x00b64:YQ==\n1\n1\n"; string(data) != want {
+		t.Fatalf("got %q, wanted %q", data, want)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SET", "\x00b64:YQ==", "1"}; !reflect.DeepEqual(srv.Commands()[0], want) {
+		t.Fatalf("Exec sent %q, wanted %q as encoded", srv.Commands()[0], want)
+	}
+	if !reflect.DeepEqual(counts, []int{1, 1}) {
+		t.Fatalf("got FrameWriter counts %v, wanted [1 1]", counts)
+	}
+
+	opt = srv.options()
+	opt.ReadOnly = true
+	ro := skytable.NewClient(opt)
+	defer ro.Close()
+
+	pipe = ro.Pipeline()
+	pipe.Set(ctx, "a", "1")
+	if _, err := pipe.Encode(); !errors.Is(err, skytable.ErrReadOnly) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrReadOnly)
+	}
+}
+
+func TestDoMulti(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var pipelines int
+	rdb.AddHook(&hook{
+		afterProcessPipeline: func(ctx context.Context, cmds []skytable.Cmder) error {
+			pipelines++
+			return nil
+		},
+	})
+
+	cmds, err := rdb.DoMulti(ctx,
+		[]interface{}{"SET", "a", "1"},
+		[]interface{}{"GET", "a"},
+		[]interface{}{"GET", "missing"},
+	)
+	if err != skytable.Nil {
+		t.Fatalf("got %v, wanted Nil from the failed GET", err)
+	}
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, wanted 3", len(cmds))
+	}
+	if err := cmds[0].Err(); err != nil {
+		t.Fatalf("SET: %v", err)
+	}
+	if got, err := cmds[1].Text(); err != nil || got != "1" {
+		t.Fatalf("GET: got %q, %v, wanted 1, <nil>", got, err)
+	}
+	if err := cmds[2].Err(); err != skytable.Nil {
+		t.Fatalf("GET missing: got %v, wanted Nil", err)
+	}
+	if pipelines != 1 {
+		t.Fatalf("got %d pipelines, wanted 1", pipelines)
+	}
+}
@@ -185,6 +185,19 @@ var _ = Describe("races", func() {
 		})
 	})
 
+	It("should Incr", func() {
+		perform(C, func(id int) {
+			for i := 0; i < N; i++ {
+				_, err := client.Incr(ctx, "key", 1)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		n, err := client.Get(ctx, "key").Int64()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(int64(C * N)))
+	})
+
 	// It("should Pipeline", func() {
 	//   pipe := client.Pipeline()
 	//   perform(N, func(id int) {
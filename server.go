@@ -0,0 +1,300 @@
+package skytable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ServerInfo describes the server as reported by SYS INFO and SYS METRIC.
+type ServerInfo struct {
+	// Supported is false when the server rejected the SYS action, either
+	// because it doesn't know it or because the user may not run it.
+	// The fields that could not be read are then left empty.
+	Supported bool
+
+	Version  string
+	Protocol string
+	Health   string
+}
+
+// HealthStatus is the result of HealthCheck.
+type HealthStatus struct {
+	// Supported is false when the server rejected SYS METRIC health.
+	// Healthy then only reflects that the server answered HEYA.
+	Supported bool
+	Healthy   bool
+	Status    string
+}
+
+// SystemStatus is the result of SystemStatus.
+type SystemStatus struct {
+	Version         string
+	ProtocolVersion float64
+	Health          string
+	// StorageBytes is the number of bytes used for on-disk storage.
+	StorageBytes uint64
+
+	// Errors holds the error of each field that could not be read, keyed
+	// by the SYS property or metric, e.g. "storage". The field is then
+	// left empty.
+	Errors map[string]error
+}
+
+// Capabilities is the feature set of the server, as returned by
+// ServerCapabilities.
+type Capabilities struct {
+	// ProtocolVersion is the Skyhash version reported by SYS INFO
+	// protover, or 0 if it could not be read.
+	ProtocolVersion float64
+	// Pipelining is false when the server rejects pipelines with
+	// ErrPipelineNotSupported.
+	Pipelining bool
+	// AuthEnabled reports whether authn is enabled, see AuthStatus.
+	AuthEnabled bool
+
+	// Errors holds the error of each probe that failed, keyed by
+	// "protover", "pipeline" or "auth". The capability it probes is then
+	// reported as missing.
+	Errors map[string]error
+}
+
+// capabilitiesCache keeps the Capabilities of the server once probed.
+type capabilitiesCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+// ServerCapabilities probes which features the server supports. The first
+// successful call sends a few commands and caches the result for the life
+// of the client; the Capabilities returned must not be modified.
+//
+// A probe rejected by the server, or locally by Options.ReadOnly or
+// Options.DeniedCommands, doesn't make it fail: the feature is reported
+// as missing and the error kept in Capabilities.Errors. An error is only
+// returned, and nothing cached, if the server can't be reached.
+//
+// Only commands without side effects are sent, so snapshot support isn't
+// probed: MKSNAP can't be tried without taking a snapshot.
+func (c *Client) ServerCapabilities(ctx context.Context) (*Capabilities, error) {
+	c.caps.mu.Lock()
+	defer c.caps.mu.Unlock()
+	if c.caps.caps != nil {
+		return c.caps.caps, nil
+	}
+
+	caps := &Capabilities{Errors: make(map[string]error)}
+	probe := func(name string, err error) error {
+		if err != nil && isProbeRejected(err) {
+			caps.Errors[name] = err
+			return nil
+		}
+		return err
+	}
+
+	protover := NewCmd(ctx, "SYS", "INFO", "protover")
+	_ = c.Process(ctx, protover)
+	version, err := protover.Float64()
+	if err := probe("protover", err); err != nil {
+		return nil, err
+	}
+	caps.ProtocolVersion = version
+
+	pipe := c.Pipeline()
+	heyas := []*StringCmd{pipe.Heya(ctx, ""), pipe.Heya(ctx, "")}
+	if _, err := pipe.Exec(ctx); err != nil && !isProbeRejected(err) {
+		return nil, err
+	}
+	caps.Pipelining = true
+	for _, cmd := range heyas {
+		if err := cmd.Err(); err != nil {
+			_ = probe("pipeline", err)
+			caps.Pipelining = false
+		}
+	}
+
+	auth, err := c.AuthStatus(ctx).Result()
+	if err := probe("auth", err); err != nil {
+		return nil, err
+	}
+	caps.AuthEnabled = auth.Enabled
+
+	c.caps.caps = caps
+	return caps, nil
+}
+
+// isProbeRejected reports whether err means that a capability probe was
+// refused, by the server or by the client's own command filters, so that
+// the capability is unknown rather than the probe failed.
+func isProbeRejected(err error) bool {
+	return isSkytableError(err) ||
+		errors.Is(err, ErrReadOnly) || errors.Is(err, ErrCommandNotAllowed)
+}
+
+// isUnsupported reports whether err means that the server can't or won't
+// run the action, as opposed to a network or protocol failure.
+func isUnsupported(err error) bool {
+	switch err {
+	case UnknownActionError, AuthnRealmError:
+		return true
+	}
+	return false
+}
+
+// ServerInfo returns the server version, protocol and health.
+// Servers that reject SYS don't make it fail: a partial ServerInfo is
+// returned with Supported set to false.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	info := &ServerInfo{Supported: true}
+	fields := []struct {
+		cmd *StringCmd
+		val *string
+	}{
+		{c.SysInfo(ctx, "version"), &info.Version},
+		{c.SysInfo(ctx, "protocol"), &info.Protocol},
+		{c.SysMetric(ctx, "health"), &info.Health},
+	}
+	for _, f := range fields {
+		val, err := f.cmd.Result()
+		if err != nil {
+			if isUnsupported(err) {
+				info.Supported = false
+				continue
+			}
+			return nil, err
+		}
+		*f.val = val
+	}
+	return info, nil
+}
+
+// HealthCheck reports whether the server is healthy.
+// It fails only if the server can't be reached; if SYS METRIC health is
+// rejected, a server answering HEYA is considered healthy.
+func (c *Client) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	if err := c.Heya(ctx, "").Err(); err != nil {
+		return nil, err
+	}
+
+	status, err := c.SysMetric(ctx, "health").Result()
+	if err != nil {
+		if isUnsupported(err) {
+			return &HealthStatus{Healthy: true}, nil
+		}
+		return nil, err
+	}
+	return &HealthStatus{
+		Supported: true,
+		Healthy:   status == "good",
+		Status:    status,
+	}, nil
+}
+
+// VerifyEcho sends payload with Echo and checks that the server echoes it
+// back unchanged, returning an error wrapping ErrEchoMismatch otherwise.
+func (c *Client) VerifyEcho(ctx context.Context, payload string) error {
+	got, err := c.Echo(ctx, payload).Result()
+	if err != nil {
+		return err
+	}
+	if got != payload {
+		return fmt.Errorf("%w: sent %.100q, got %.100q", ErrEchoMismatch, payload, got)
+	}
+	return nil
+}
+
+// SystemStatus reads the server version, protocol version, health and
+// storage in one pipeline. A field that can't be read is reported in
+// SystemStatus.Errors without failing the others; an error is only returned
+// if the pipeline itself fails, e.g. because the server can't be reached.
+func (c *Client) SystemStatus(ctx context.Context) (*SystemStatus, error) {
+	var version, protover, health, storage *Cmd
+	_, err := c.Pipelined(ctx, func(pipe Pipeliner) error {
+		version = pipe.Do(ctx, "SYS", "INFO", "version")
+		protover = pipe.Do(ctx, "SYS", "INFO", "protover")
+		health = pipe.Do(ctx, "SYS", "METRIC", "health")
+		storage = pipe.Do(ctx, "SYS", "METRIC", "storage")
+		return nil
+	})
+	if err != nil && !isSkytableError(err) {
+		return nil, err
+	}
+
+	status := &SystemStatus{Errors: make(map[string]error)}
+	if status.Version, err = version.Text(); err != nil {
+		status.Errors["version"] = err
+	}
+	if status.ProtocolVersion, err = protover.Float64(); err != nil {
+		status.Errors["protover"] = err
+	}
+	if status.Health, err = health.Text(); err != nil {
+		status.Errors["health"] = err
+	}
+	if status.StorageBytes, err = storage.Uint64(); err != nil {
+		status.Errors["storage"] = err
+	}
+	return status, nil
+}
+
+// DbSizeSample is a table size read by WatchDbSize.
+type DbSizeSample struct {
+	Time time.Time
+	Size int64
+	// Err is set when the size could not be read. Size is then 0.
+	Err error
+}
+
+// WatchDbSize reads the number of entries in entity, or in the current
+// table if entity is empty, right away and then every interval, and sends
+// each reading on the returned channel until ctx is done. Readings that
+// fail are sent with their error and polling goes on, except for
+// ContainerNotFoundError: the channel is closed after that sample. The
+// channel is also closed when ctx is done.
+//
+// interval must be positive; otherwise a single sample with
+// ErrInvalidInterval is sent and the channel closed, without reading.
+//
+// The next reading is taken only once the previous sample was received,
+// so a slow receiver gets fewer samples, never stale ones.
+func (c *Client) WatchDbSize(ctx context.Context, entity string, interval time.Duration) <-chan DbSizeSample {
+	if interval <= 0 {
+		ch := make(chan DbSizeSample, 1)
+		ch <- DbSizeSample{
+			Time: time.Now(),
+			Err:  fmt.Errorf("%w: WatchDbSize %s", ErrInvalidInterval, interval),
+		}
+		close(ch)
+		return ch
+	}
+
+	ticker := time.NewTicker(interval)
+	ch := make(chan DbSizeSample)
+	go func() {
+		defer close(ch)
+		defer ticker.Stop()
+
+		for {
+			size, err := c.DbSize(ctx, entity).Result()
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- DbSizeSample{Time: time.Now(), Size: size, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err == ContainerNotFoundError {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
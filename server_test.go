@@ -0,0 +1,375 @@
+package skytable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/satvik007/skytable-go"
+)
+
+func TestServerInfoUnsupported(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "HEYA":
+			return fakeString("HEY!")
+		default:
+			return fakeError("Unknown action")
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	info, err := rdb.ServerInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Supported || info.Version != "" {
+		t.Fatalf("got %+v, wanted an unsupported ServerInfo", info)
+	}
+
+	health, err := rdb.HealthCheck(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Supported || !health.Healthy {
+		t.Fatalf("got %+v, wanted a healthy unsupported HealthStatus", health)
+	}
+}
+
+func TestSystemStatus(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[2] {
+		case "version":
+			return fakeString("0.7.5")
+		case "protover":
+			return "%3\n1.2\n"
+		case "health":
+			return fakeString("good")
+		case "storage":
+			return fakeInt(4096)
+		}
+		return fakeError("Unknown action")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	status, err := rdb.SystemStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &skytable.SystemStatus{
+		Version:         "0.7.5",
+		ProtocolVersion: 1.2,
+		Health:          "good",
+		StorageBytes:    4096,
+		Errors:          map[string]error{},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Fatalf("got %+v, wanted %+v", status, want)
+	}
+}
+
+func TestSystemStatusPartial(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[2] == "storage" {
+			return fakeError("Unknown action")
+		}
+		return fakeString("good")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	status, err := rdb.SystemStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Health != "good" {
+		t.Fatalf("got health %q, wanted %q", status.Health, "good")
+	}
+	if err := status.Errors["storage"]; err != skytable.UnknownActionError {
+		t.Fatalf("got %v, wanted %v", err, skytable.UnknownActionError)
+	}
+}
+
+func TestServerCapabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     func(*skytable.Options)
+		replies map[string]string
+		want    *skytable.Capabilities
+	}{{
+		name: "full",
+		replies: map[string]string{
+			"SYS":  "%3\n1.2\n",
+			"HEYA": fakeString("HEY!"),
+			"AUTH": fakeStatus(11),
+		},
+		want: &skytable.Capabilities{
+			ProtocolVersion: 1.2,
+			Pipelining:      true,
+			AuthEnabled:     true,
+			Errors:          map[string]error{},
+		},
+	}, {
+		name: "minimal",
+		replies: map[string]string{
+			"SYS":  fakeError("Unknown action"),
+			"HEYA": fakeError("pipeline-not-supported-yet"),
+			"AUTH": fakeError("err-auth-disabled"),
+		},
+		want: &skytable.Capabilities{
+			Errors: map[string]error{
+				"protover": skytable.UnknownActionError,
+				"pipeline": skytable.ErrPipelineNotSupported,
+			},
+		},
+	}, {
+		name: "filtered",
+		opt: func(opt *skytable.Options) {
+			opt.ReadOnly = true
+			opt.AllowedCommands = []string{"heya"}
+		},
+		replies: map[string]string{
+			"HEYA": fakeString("HEY!"),
+		},
+		want: &skytable.Capabilities{
+			Pipelining: true,
+			Errors: map[string]error{
+				"protover": skytable.ErrCommandNotAllowed,
+				"auth":     skytable.ErrCommandNotAllowed,
+			},
+		},
+	}}
+	for _, tt := range tests {
+		srv := newFakeServer(func(args []string) string {
+			return tt.replies[args[0]]
+		})
+		opt := srv.options()
+		if tt.opt != nil {
+			tt.opt(opt)
+		}
+		rdb := skytable.NewClient(opt)
+
+		caps, err := rdb.ServerCapabilities(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(caps.Errors) != len(tt.want.Errors) {
+			t.Fatalf("%s: got errors %v, wanted %v", tt.name, caps.Errors, tt.want.Errors)
+		}
+		for name, want := range tt.want.Errors {
+			if !errors.Is(caps.Errors[name], want) {
+				t.Fatalf("%s: got %s error %v, wanted %v", tt.name, name, caps.Errors[name], want)
+			}
+		}
+		got := *caps
+		got.Errors = tt.want.Errors
+		if !reflect.DeepEqual(&got, tt.want) {
+			t.Fatalf("%s: got %+v, wanted %+v", tt.name, caps, tt.want)
+		}
+		for _, args := range srv.Commands() {
+			if _, ok := tt.replies[args[0]]; !ok {
+				t.Fatalf("%s: unexpected probe %v", tt.name, args)
+			}
+		}
+
+		n := len(srv.Commands())
+		if cached, err := rdb.ServerCapabilities(ctx); err != nil || cached != caps {
+			t.Fatalf("%s: got %+v, %v, wanted the cached capabilities", tt.name, cached, err)
+		}
+		if len(srv.Commands()) != n {
+			t.Fatalf("%s: the capabilities were probed twice", tt.name)
+		}
+		rdb.Close()
+	}
+}
+
+func TestVerifyEcho(t *testing.T) {
+	var corrupt bool
+	srv := newFakeServer(func(args []string) string {
+		if corrupt {
+			return fakeString(args[1] + "!")
+		}
+		return fakeString(args[1])
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if err := rdb.VerifyEcho(ctx, "ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt = true
+	if err := rdb.VerifyEcho(ctx, "ping"); !errors.Is(err, skytable.ErrEchoMismatch) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrEchoMismatch)
+	}
+}
+
+var _ = Describe("Server", func() {
+	var client *skytable.Client
+
+	BeforeEach(func() {
+		client = skytable.NewClient(skytableOptions())
+	})
+
+	AfterEach(func() {
+		Expect(client.Close()).NotTo(HaveOccurred())
+	})
+
+	It("should return ServerInfo", func() {
+		info, err := client.ServerInfo(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Supported).To(BeTrue())
+		Expect(info.Version).NotTo(BeEmpty())
+		Expect(info.Health).To(Equal("good"))
+	})
+
+	It("should HealthCheck", func() {
+		health, err := client.HealthCheck(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(health.Supported).To(BeTrue())
+		Expect(health.Healthy).To(BeTrue())
+	})
+})
+
+func TestDbSize(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch {
+		case len(args) == 1:
+			return fakeInt(1234567)
+		case args[1] == "default:present":
+			return fakeInt(42)
+		default:
+			return fakeError("container-not-found")
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	cmd := rdb.DbSize(ctx, "default:present")
+	if n, err := cmd.Result(); err != nil || n != 42 {
+		t.Fatalf("got %d, %v, wanted 42, <nil>", n, err)
+	}
+
+	cmd = rdb.DbSize(ctx, "default:missing")
+	if err := cmd.Err(); err != skytable.ContainerNotFoundError {
+		t.Fatalf("got %v, wanted ContainerNotFoundError", err)
+	}
+	if cmd.Val() != 0 || cmd.Human() != "" {
+		t.Fatalf("got %d (%q), wanted no count", cmd.Val(), cmd.Human())
+	}
+
+	if got := rdb.DbSize(ctx, "").Human(); got != "1,234,567" {
+		t.Fatalf("got %q, wanted 1,234,567", got)
+	}
+}
+
+func TestWatchDbSize(t *testing.T) {
+	var n int
+	srv := newFakeServer(func(args []string) string {
+		if n++; n > 2 {
+			return fakeError("container-not-found")
+		}
+		return fakeInt(10 * n)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var samples []skytable.DbSizeSample
+	for sample := range rdb.WatchDbSize(ctx, "ks:t", time.Millisecond) {
+		samples = append(samples, sample)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("got %d samples, wanted 3: %+v", len(samples), samples)
+	}
+	for i, want := range []int64{10, 20} {
+		if s := samples[i]; s.Size != want || s.Err != nil || s.Time.IsZero() {
+			t.Fatalf("sample %d: got %+v, wanted size %d", i, s, want)
+		}
+	}
+	if err := samples[2].Err; err != skytable.ContainerNotFoundError {
+		t.Fatalf("got %v, wanted a final ContainerNotFoundError sample", err)
+	}
+	if got := srv.Commands()[0]; !reflect.DeepEqual(got, []string{"DBSIZE", "ks:t"}) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestWatchDbSizeCancel(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeInt(1)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	ch := rdb.WatchDbSize(ctx, "", time.Hour)
+	if s := <-ch; s.Size != 1 || s.Err != nil {
+		t.Fatalf("got %+v, wanted size 1", s)
+	}
+	cancel()
+	select {
+	case s, ok := <-ch:
+		if ok {
+			t.Fatalf("got %+v after cancel, wanted the channel closed", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the channel was not closed after cancel")
+	}
+}
+
+func TestWatchDbSizeInvalidInterval(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeInt(1)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		var samples []skytable.DbSizeSample
+		for sample := range rdb.WatchDbSize(ctx, "", interval) {
+			samples = append(samples, sample)
+		}
+		if len(samples) != 1 || !errors.Is(samples[0].Err, skytable.ErrInvalidInterval) {
+			t.Fatalf("%s: got %+v, wanted one %v sample", interval, samples, skytable.ErrInvalidInterval)
+		}
+	}
+	if cmds := srv.Commands(); len(cmds) != 0 {
+		t.Fatalf("got %q, wanted nothing sent", cmds)
+	}
+}
+
+func TestIntCmdHuman(t *testing.T) {
+	tests := []struct {
+		val  int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{123456, "123,456"},
+		{-1234, "-1,234"},
+		{-123, "-123"},
+	}
+	for _, tt := range tests {
+		cmd := skytable.NewIntCmd(ctx)
+		cmd.SetVal(tt.val)
+		if got := cmd.Human(); got != tt.want {
+			t.Fatalf("%d: got %q, wanted %q", tt.val, got, tt.want)
+		}
+	}
+}
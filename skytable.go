@@ -39,6 +39,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -59,6 +60,38 @@ const UnknownDataTypeError = proto.UnknownDataTypeError
 const EncodingError = proto.EncodingError
 const BadCredentials = proto.BadCredentials
 const AuthnRealmError = proto.AuthnRealmError
+const UnknownActionError = proto.UnknownActionError
+const AlreadyExistsError = proto.AlreadyExistsError
+const UnknownInspectQueryError = proto.UnknownInspectQueryError
+const ContainerNotFoundError = proto.ContainerNotFoundError
+const StillInUseError = proto.StillInUseError
+const KeyspaceNotEmptyError = proto.KeyspaceNotEmptyError
+const BadContainerNameError = proto.BadContainerNameError
+const ContainerNameTooLongError = proto.ContainerNameTooLongError
+const TooManyArgsError = proto.TooManyArgsError
+const UnknownPropertyError = proto.UnknownPropertyError
+const UnknownModelError = proto.UnknownModelError
+const AuthDisabledError = proto.AuthDisabledError
+const AuthAlreadyClaimedError = proto.AuthAlreadyClaimedError
+const AuthIllegalUsernameError = proto.AuthIllegalUsernameError
+const AuthDelUserFailError = proto.AuthDelUserFailError
+
+// ErrDefaultContainerUnset is returned when a command needs a table but the
+// connection has no keyspace or table selected. See Options.AutoSelectContainer.
+const ErrDefaultContainerUnset = proto.DefaultContainerUnsetError
+
+// ErrProtectedObject is returned when a command touches a system object
+// that is not accessible to users.
+const ErrProtectedObject = proto.ProtectedObjectError
+
+// ErrWrongModel is returned when an action is run against a table of the
+// wrong data model, e.g. LGET against a keymap of scalar values. KeyType
+// tells which kind of values a table holds.
+const ErrWrongModel = proto.WrongModelError
+
+// ErrPipelineNotSupported is returned for the commands of a pipeline sent
+// to a server version that doesn't support pipelining.
+const ErrPipelineNotSupported = proto.PipelineNotSupportedError
 
 // SetLogger set custom log
 func SetLogger(logger internal.Logging) {
@@ -160,12 +193,26 @@ type baseClient struct {
 	connPool pool.Pooler
 
 	onClose func() error // hook called when client is closed
+
+	// credsGen is bumped by RefreshCredentials and BootstrapTable.
+	// Connections initialized with an older generation are closed instead
+	// of being reused.
+	credsGen *uint32
+
+	// selected holds the table set by BootstrapTable, which overrides
+	// Options.Table. It is shared with clones and Conns.
+	selected *atomic.Value
+
+	// closed is set by the first Close. It is shared with clones, which
+	// use the same pool.
+	closed *uint32
 }
 
 func newBaseClient(opt *Options, connPool pool.Pooler) *baseClient {
 	return &baseClient{
 		opt:      opt,
 		connPool: connPool,
+		closed:   new(uint32),
 	}
 }
 
@@ -186,7 +233,18 @@ func (c *baseClient) withTimeout(timeout time.Duration) *baseClient {
 }
 
 func (c *baseClient) String() string {
-	return fmt.Sprintf("Skytable<%s table:%s>", c.getAddr(), c.opt.Table)
+	return fmt.Sprintf("Skytable<%s table:%s>", c.getAddr(), c.table())
+}
+
+// table returns the table connections select when they are initialized:
+// the one set by BootstrapTable, or else Options.Table.
+func (c *baseClient) table() string {
+	if c.selected != nil {
+		if table, ok := c.selected.Load().(string); ok {
+			return table
+		}
+	}
+	return c.opt.Table
 }
 
 func (c *baseClient) getConn(ctx context.Context) (*pool.Conn, error) {
@@ -213,6 +271,12 @@ func (c *baseClient) _getConn(ctx context.Context) (*pool.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
+	for c.staleCreds(cn) {
+		c.connPool.Remove(ctx, cn, nil)
+		if cn, err = c.connPool.Get(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	if cn.Inited {
 		return cn, nil
@@ -234,6 +298,9 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 		return nil
 	}
 	cn.Inited = true
+	if c.credsGen != nil {
+		cn.Generation = atomic.LoadUint32(c.credsGen)
+	}
 
 	username, token := c.opt.Username, c.opt.Token
 	if c.opt.CredentialsProvider != nil {
@@ -249,8 +316,20 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 		}
 	}
 
-	if c.opt.Table != "" {
-		if err := conn.Use(ctx, c.opt.Table).Err(); err != nil {
+	if c.opt.VerifyProtocol {
+		if err := verifyProtocol(ctx, conn); err != nil {
+			return err
+		}
+	}
+
+	if table := c.table(); table != "" {
+		if err := conn.Use(ctx, table).Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, fn := range c.opt.InitCommands {
+		if err := fn(ctx, conn); err != nil {
 			return err
 		}
 	}
@@ -262,6 +341,24 @@ func (c *baseClient) initConn(ctx context.Context, cn *pool.Conn) error {
 	return nil
 }
 
+// verifyProtocol fails with ErrUnsupportedProtocol if the server speaks a
+// Skyhash major version outside minProtocolMajor..maxProtocolMajor.
+func verifyProtocol(ctx context.Context, conn *Conn) error {
+	cmd := NewCmd(ctx, "SYS", "INFO", "protover")
+	_ = conn.Process(ctx, cmd)
+	version, err := cmd.Float64()
+	if err != nil {
+		if isUnsupported(err) {
+			return nil
+		}
+		return err
+	}
+	if major := int(version); major < minProtocolMajor || major > maxProtocolMajor {
+		return unsupportedProtocolError{version: version}
+	}
+	return nil
+}
+
 func (c *baseClient) releaseConn(ctx context.Context, cn *pool.Conn, err error) {
 	if c.opt.Limiter != nil {
 		c.opt.Limiter.ReportResult(err)
@@ -269,11 +366,32 @@ func (c *baseClient) releaseConn(ctx context.Context, cn *pool.Conn, err error)
 
 	if isBadConn(err, false, c.opt.Addr) {
 		c.connPool.Remove(ctx, cn, err)
+	} else if c.staleCreds(cn) || c.connUsedUp(cn) {
+		c.connPool.Remove(ctx, cn, nil)
 	} else {
 		c.connPool.Put(ctx, cn)
 	}
 }
 
+// staleCreds reports whether cn was initialized before the last call to
+// RefreshCredentials.
+func (c *baseClient) staleCreds(cn *pool.Conn) bool {
+	return c.credsGen != nil && cn.Inited && cn.Generation != atomic.LoadUint32(c.credsGen)
+}
+
+// connUsedUp counts a use of cn and reports whether it has reached
+// Options.MaxConnUses and must be closed instead of returned to the pool.
+func (c *baseClient) connUsedUp(cn *pool.Conn) bool {
+	if c.opt.MaxConnUses <= 0 {
+		return false
+	}
+	// The connection of a Conn is kept until the Conn is closed.
+	if _, ok := c.connPool.(*pool.ConnPool); !ok {
+		return false
+	}
+	return cn.IncrUses() >= c.opt.MaxConnUses
+}
+
 func (c *baseClient) withConn(
 	ctx context.Context, fn func(context.Context, *pool.Conn) error,
 ) error {
@@ -293,23 +411,62 @@ func (c *baseClient) withConn(
 		return err
 	}
 
-	errc := make(chan error, 1)
-	go func() { errc <- fn(ctx, cn) }()
+	// fn runs inline; the goroutine watching done, owned by cn, interrupts
+	// its I/O if ctx is canceled before the deadline.
+	cn.Watch(done)
+	err = fn(ctx, cn)
+	if cn.Unwatch() {
+		err = ctx.Err()
+	}
+	return err
+}
 
-	select {
-	case <-done:
-		_ = cn.Close()
-		// Wait for the goroutine to finish and send something.
-		<-errc
+// prepareCmd applies the options that change how cmd decodes its reply.
+func (c *baseClient) prepareCmd(cmd Cmder) {
+	if c.opt.EncodeBinaryKeys {
+		cmd.encodeKeys()
+		if cmd, ok := cmd.(*StringSliceCmd); ok && cmd.Name() == "lskeys" {
+			cmd.decodeKeys = true
+		}
+	}
+	if c.opt.EmulateExpiry {
+		if cmd, ok := cmd.(*StringSliceCmd); ok && cmd.Name() == "lskeys" {
+			cmd.skipExpiryKeys = true
+		}
+	}
+}
 
-		err = ctx.Err()
-		return err
-	case err = <-errc:
-		return err
+// checkCmd returns the error of a command that the options forbid to send.
+func (c *baseClient) checkCmd(cmd Cmder) error {
+	if c.opt.ReadOnly && isWriteCmd(cmd) {
+		return ErrReadOnly
+	}
+	if len(c.opt.AllowedCommands) == 0 && len(c.opt.DeniedCommands) == 0 {
+		return nil
+	}
+	name := cmd.FullName()
+	if len(c.opt.AllowedCommands) > 0 && !containsFold(c.opt.AllowedCommands, name) ||
+		containsFold(c.opt.DeniedCommands, name) {
+		return fmt.Errorf("%w: %s", ErrCommandNotAllowed, name)
 	}
+	return nil
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
+	if err := c.checkCmd(cmd); err != nil {
+		return err
+	}
+	c.prepareCmd(cmd)
+
 	var lastErr error
 	for attempt := 0; attempt <= c.opt.MaxRetries; attempt++ {
 		attempt := attempt
@@ -321,7 +478,80 @@ func (c *baseClient) process(ctx context.Context, cmd Cmder) error {
 
 		lastErr = err
 	}
-	return lastErr
+	return serverUnavailable(lastErr)
+}
+
+// recreateMissing runs Options.AutoCreateOnMissing for a command that
+// failed with ContainerNotFoundError, selects the table again and resends
+// the command once. Only commands on the keys of the client's table do
+// so: DDL and commands naming another entity keep the error.
+func (c *baseClient) recreateMissing(ctx context.Context, cn *pool.Conn, cmd Cmder, retryTimeout *uint32) error {
+	table := c.table()
+	if table == "" || keyLayouts[cmd.Name()] == 0 {
+		return ContainerNotFoundError
+	}
+	if err := c.opt.AutoCreateOnMissing(ctx, table); err != nil {
+		return fmt.Errorf("skytable: can't recreate %q: %w", table, err)
+	}
+	use := NewStatusCmd(ctx, "USE", table)
+	if err := c.roundTrip(ctx, cn, use, retryTimeout); err != nil {
+		return err
+	}
+	return c.roundTrip(ctx, cn, cmd, retryTimeout)
+}
+
+// writeMetaFrame starts a request of n commands with Options.FrameWriter,
+// or with a Skyhash meta frame by default.
+func (c *baseClient) writeMetaFrame(wr *proto.Writer, n int) error {
+	if c.opt.FrameWriter != nil {
+		return c.opt.FrameWriter(wr, n)
+	}
+	return wr.WriteMetaFrame(n)
+}
+
+// roundTrip sends cmd on cn and reads its reply. retryTimeout is cleared
+// when a read fails after cmd was sent and cmd can't be safely resent.
+func (c *baseClient) roundTrip(
+	ctx context.Context, cn *pool.Conn, cmd Cmder, retryTimeout *uint32,
+) error {
+	start := time.Now()
+	err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
+		if err := c.writeMetaFrame(wr, 1); err != nil {
+			return err
+		}
+		return writeCmd(wr, cmd)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = cn.WithReader(ctx, c.cmdTimeout(cmd), func(rd *proto.Reader) error {
+		cnt, err := rd.ReadMetaFrame()
+		if err != nil {
+			return err
+		}
+		if cnt != 1 {
+			return discardReplies(rd, cnt, 1)
+		}
+		return readCmdReply(rd, cmd)
+	})
+	if err != nil {
+		// The command has already been sent, so the server may have
+		// applied it. Only idempotent commands are retried on timeout.
+		if cmd.readTimeout() == nil && cmd.Idempotent() {
+			atomic.StoreUint32(retryTimeout, 1)
+		} else {
+			atomic.StoreUint32(retryTimeout, 0)
+		}
+		// An error reply still took a full round trip.
+		if isSkytableError(err) {
+			cn.RecordRTT(time.Since(start))
+		}
+		return err
+	}
+
+	cn.RecordRTT(time.Since(start))
+	return nil
 }
 
 func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool, error) {
@@ -330,37 +560,32 @@ func (c *baseClient) _process(ctx context.Context, cmd Cmder, attempt int) (bool
 			return false, err
 		}
 	}
+	cmd.addAttempt()
+
+	if c.opt.Transport != nil {
+		err := c.transportRoundTrip(ctx, []Cmder{cmd})
+		if err == nil {
+			err = cmd.Err()
+		}
+		return shouldRetry(err, cmd.Idempotent()), err
+	}
 
 	retryTimeout := uint32(1)
 	err := c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
-		err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
-			if err := wr.WriteMetaFrame(1); err != nil {
+		err := c.roundTrip(ctx, cn, cmd, &retryTimeout)
+		if table := c.table(); err == ErrDefaultContainerUnset && c.opt.AutoSelectContainer && table != "" {
+			// The connection has no table selected anymore, e.g. because
+			// it was dropped. Select Options.Table again and resend once.
+			use := NewStatusCmd(ctx, "USE", table)
+			if err := c.roundTrip(ctx, cn, use, &retryTimeout); err != nil {
 				return err
 			}
-			return writeCmd(wr, cmd)
-		})
-		if err != nil {
-			return err
+			err = c.roundTrip(ctx, cn, cmd, &retryTimeout)
 		}
-
-		err = cn.WithReader(ctx, c.cmdTimeout(cmd), func(rd *proto.Reader) error {
-			cnt, err := rd.ReadMetaFrame()
-			if err != nil {
-				return err
-			}
-			if cnt != 1 {
-				return fmt.Errorf("skytable: expected %d commands, got %d", 1, cnt)
-			}
-			return cmd.readReply(rd)
-		})
-		if err != nil {
-			if cmd.readTimeout() == nil {
-				atomic.StoreUint32(&retryTimeout, 1)
-			}
-			return err
+		if err == ContainerNotFoundError && c.opt.AutoCreateOnMissing != nil {
+			err = c.recreateMissing(ctx, cn, cmd, &retryTimeout)
 		}
-
-		return nil
+		return err
 	})
 	if err == nil {
 		return false, nil
@@ -382,6 +607,9 @@ func (c *baseClient) cmdTimeout(cmd Cmder) time.Duration {
 		}
 		return t + 10*time.Second
 	}
+	if cmd.Name() == "flushdb" {
+		return c.opt.FlushDBTimeout
+	}
 	return c.opt.ReadTimeout
 }
 
@@ -389,7 +617,13 @@ func (c *baseClient) cmdTimeout(cmd Cmder) time.Duration {
 //
 // It is rare to Close a Client, as the Client is meant to be
 // long-lived and shared between many goroutines.
+//
+// Close is idempotent: calls after the first return nil and do nothing.
 func (c *baseClient) Close() error {
+	if !atomic.CompareAndSwapUint32(c.closed, 0, 1) {
+		return nil
+	}
+
 	var firstErr error
 	if c.onClose != nil {
 		if err := c.onClose(); err != nil {
@@ -407,6 +641,13 @@ func (c *baseClient) getAddr() string {
 }
 
 func (c *baseClient) processPipeline(ctx context.Context, cmds []Cmder) error {
+	for _, cmd := range cmds {
+		if err := c.checkCmd(cmd); err != nil {
+			setCmdsErr(cmds, err)
+			return err
+		}
+		c.prepareCmd(cmd)
+	}
 	return c.generalProcessPipeline(ctx, cmds, c.pipelineProcessCmds)
 }
 
@@ -433,6 +674,17 @@ func (c *baseClient) _generalProcessPipeline(
 				return err
 			}
 		}
+		for _, cmd := range cmds {
+			cmd.addAttempt()
+		}
+
+		if c.opt.Transport != nil {
+			lastErr = c.transportRoundTrip(ctx, cmds)
+			if lastErr == nil || !shouldRetry(lastErr, true) {
+				return lastErr
+			}
+			continue
+		}
 
 		var canRetry bool
 		lastErr = c.withConn(ctx, func(ctx context.Context, cn *pool.Conn) error {
@@ -444,14 +696,14 @@ func (c *baseClient) _generalProcessPipeline(
 			return lastErr
 		}
 	}
-	return lastErr
+	return serverUnavailable(lastErr)
 }
 
 func (c *baseClient) pipelineProcessCmds(
 	ctx context.Context, cn *pool.Conn, cmds []Cmder,
 ) (bool, error) {
 	err := cn.WithWriter(ctx, c.opt.WriteTimeout, func(wr *proto.Writer) error {
-		return writeCmds(wr, cmds)
+		return c.writeCmds(wr, cmds)
 	})
 	if err != nil {
 		return true, err
@@ -469,10 +721,10 @@ func pipelineReadCmds(rd *proto.Reader, cmds []Cmder) error {
 		return err
 	}
 	if cnt != len(cmds) {
-		return fmt.Errorf("skytable: expected %d commands, got %d", len(cmds), cnt)
+		return discardReplies(rd, cnt, len(cmds))
 	}
 	for _, cmd := range cmds {
-		err := cmd.readReply(rd)
+		err := readCmdReply(rd, cmd)
 		cmd.SetErr(err)
 		if err != nil && !isSkytableError(err) {
 			return err
@@ -481,6 +733,17 @@ func pipelineReadCmds(rd *proto.Reader, cmds []Cmder) error {
 	return nil
 }
 
+// discardReplies drops the got replies of a frame that doesn't match the
+// want commands sent, so the connection stays in sync and can be reused.
+func discardReplies(rd *proto.Reader, got, want int) error {
+	for i := 0; i < got; i++ {
+		if err := rd.DiscardNext(); err != nil {
+			return err
+		}
+	}
+	return proto.SkytableError(fmt.Sprintf("skytable: expected %d commands, got %d", want, got))
+}
+
 // ------------------------------------------------------------------------------
 
 // Client is a Skytable client representing a pool of zero or more underlying connections.
@@ -493,6 +756,12 @@ type Client struct {
 	cmdable
 	hooks
 	ctx context.Context
+
+	keyLocks *keyLocker
+	expiry   *expiryReaper
+	tables   *tableInfoCache
+	caps     *capabilitiesCache
+	latency  *latencyRecorder
 }
 
 // NewClient returns a client to the Skytable Server specified by Options.
@@ -502,12 +771,41 @@ func NewClient(opt *Options) *Client {
 	c := Client{
 		baseClient: newBaseClient(opt, newConnPool(opt)),
 		ctx:        context.Background(),
+		keyLocks:   new(keyLocker),
+		tables:     new(tableInfoCache),
+		caps:       new(capabilitiesCache),
+		expiry:     newExpiryReaper(),
 	}
 	c.cmdable = c.Process
+	c.onClose = c.expiry.close
+	c.credsGen = new(uint32)
+	c.selected = new(atomic.Value)
+	if opt.EnableLatencyHistograms {
+		c.latency = newLatencyRecorder()
+		c.AddHook(c.latency)
+	}
 
 	return &c
 }
 
+// RefreshCredentials makes the client log in again with the current
+// credentials, e.g. after the output of Options.CredentialsProvider changed.
+// Pooled connections authenticated before the call are closed instead of
+// being reused, and new connections are dialed as needed; connections in
+// use finish their command first.
+func (c *Client) RefreshCredentials() {
+	atomic.AddUint32(c.credsGen, 1)
+}
+
+// NewClientWithError is like NewClient, but it validates the options first
+// and returns the error reported by Options.Validate.
+func NewClientWithError(opt *Options) (*Client, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	return NewClient(opt), nil
+}
+
 func (c *Client) clone() *Client {
 	clone := *c
 	clone.cmdable = clone.Process
@@ -522,7 +820,48 @@ func (c *Client) WithTimeout(timeout time.Duration) *Client {
 }
 
 func (c *Client) Conn() *Conn {
-	return newConn(c.opt, pool.NewStickyConnPool(c.connPool))
+	conn := newConn(c.opt, pool.NewStickyConnPool(c.connPool))
+	conn.selected = c.selected
+	return conn
+}
+
+// Pinned returns a Conn holding one connection for the scope of a request,
+// e.g. so that a Get reads what a Set before it wrote on the same
+// connection even behind a proxy or with replicas, and a release func
+// that returns the connection to the pool. Unlike with Conn, the
+// connection is taken right away; if that fails, the first command tries
+// again and returns the error. release may be called more than once.
+//
+// Session is the same, for callers that only need Cmdable and want the
+// error of taking the connection up front.
+func (c *Client) Pinned(ctx context.Context) (conn *Conn, release func()) {
+	conn, _ = c.pinConn(ctx)
+	return conn, func() {
+		_ = conn.Close()
+	}
+}
+
+// pinConn returns a Conn whose connection is taken from the pool now,
+// along with the error of taking it. The Conn is usable either way.
+func (c *Client) pinConn(ctx context.Context) (*Conn, error) {
+	conn := c.Conn()
+	return conn, conn.pin(ctx)
+}
+
+// withScratchConn runs fn on a new connection that is closed afterwards
+// instead of being returned to the pool, so fn is free to change its
+// table with USE.
+func (c *Client) withScratchConn(ctx context.Context, fn func(*Conn) error) error {
+	cn, err := c.connPool.NewConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.connPool.CloseConn(cn)
+
+	if err := c.initConn(ctx, cn); err != nil {
+		return err
+	}
+	return fn(newConn(c.opt, pool.NewSingleConnPool(c.connPool, cn)))
 }
 
 // Do creates a Cmd from the args and processes the cmd.
@@ -532,12 +871,116 @@ func (c *Client) Do(ctx context.Context, args ...interface{}) *Cmd {
 	return cmd
 }
 
+// DoMulti sends a Cmd for each of cmds, in one pipeline, and returns them
+// in the same order. Each Cmd holds its own reply or error; the error
+// returned is the one of the first failed command, if any.
+func (c *Client) DoMulti(ctx context.Context, cmds ...[]interface{}) ([]*Cmd, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	res := make([]*Cmd, len(cmds))
+	cmders := make([]Cmder, len(cmds))
+	for i, args := range cmds {
+		res[i] = NewCmd(ctx, args...)
+		cmders[i] = res[i]
+	}
+	return res, c.processPipeline(ctx, cmders)
+}
+
+// Fire sends a command built from args and throws its reply away without
+// decoding it, even if the reply is an error; only errors sending the
+// command or reading the reply are returned. Fire still waits for the
+// reply, so that the connection stays in sync and can be reused.
+func (c *Client) Fire(ctx context.Context, args ...interface{}) error {
+	cmd := &discardCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+	return c.Process(ctx, cmd)
+}
+
+// Context returns the client's base context, context.Background unless
+// set with WithContext.
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
+// WithContext returns a clone of the client whose commands also obey ctx.
+// The context passed to each command still applies: a command is canceled
+// when either context is done, its deadline is the earlier of the two,
+// and values are looked up in the per-call context only.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	clone := c.clone()
+	clone.ctx = ctx
+	return clone
+}
+
+// withClientContext derives a context from the per-call ctx that is also
+// done when the client's base context is.
+func (c *Client) withClientContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	done := c.ctx.Done()
+	if done == nil {
+		return ctx, func() {}
+	}
+
+	var cancel context.CancelFunc
+	if deadline, ok := c.ctx.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	if c.ctx.Err() == context.Canceled {
+		cancel()
+		return ctx, cancel
+	}
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 func (c *Client) Process(ctx context.Context, cmd Cmder) error {
-	return c.hooks.process(ctx, cmd, c.baseClient.process)
+	ctx, cancel := c.withClientContext(ctx)
+	defer cancel()
+	err := c.hooks.process(ctx, cmd, c.baseClient.process)
+	c.tables.invalidate(cmd)
+	return err
+}
+
+// ProcessWithHooks is like Process, but also runs the given hooks for
+// this command only. They run inside the client's hooks: their
+// BeforeProcess is called after the client's hooks and their
+// AfterProcess before.
+func (c *Client) ProcessWithHooks(ctx context.Context, cmd Cmder, hs ...Hook) error {
+	local := c.hooks
+	local.lock()
+	for _, h := range hs {
+		local.AddHook(h)
+	}
+	ctx, cancel := c.withClientContext(ctx)
+	defer cancel()
+	err := local.process(ctx, cmd, c.baseClient.process)
+	c.tables.invalidate(cmd)
+	return err
 }
 
 func (c *Client) processPipeline(ctx context.Context, cmds []Cmder) error {
-	return c.hooks.processPipeline(ctx, cmds, c.baseClient.processPipeline)
+	ctx, cancel := c.withClientContext(ctx)
+	defer cancel()
+	err := c.hooks.processPipeline(ctx, cmds, c.baseClient.processPipeline)
+	for _, cmd := range cmds {
+		c.tables.invalidate(cmd)
+	}
+	return err
 }
 
 // Options returns read-only Options that were used to create the client.
@@ -545,21 +988,89 @@ func (c *Client) Options() *Options {
 	return c.opt
 }
 
+// PoolMode returns the order in which idle connections are reused:
+// "fifo" when Options.PoolFIFO is set and "lifo" otherwise.
+func (c *Client) PoolMode() string {
+	if c.opt.PoolFIFO {
+		return "fifo"
+	}
+	return "lifo"
+}
+
 type PoolStats pool.Stats
 
+// IdleReapStrategy selects which stale idle connections the pool closes
+// when there are more than Options.IdleReapLimit.
+type IdleReapStrategy = pool.IdleReapStrategy
+
+// ProtoWriter writes Skyhash frames and arguments, see Options.FrameWriter.
+type ProtoWriter = proto.Writer
+
+const (
+	LongestIdle   = pool.LongestIdle
+	OldestCreated = pool.OldestCreated
+)
+
 // PoolStats returns connection pool stats.
 func (c *Client) PoolStats() *PoolStats {
 	stats := c.connPool.Stats()
 	return (*PoolStats)(stats)
 }
 
+// ConnRTTs returns the round-trip time of each pooled connection that has
+// sent a command, as a moving average of the time between writing a
+// command and reading its reply, pipelines excluded. It is meant for
+// debugging and latency-aware routing; the order is unspecified.
+func (c *Client) ConnRTTs() []time.Duration {
+	if p, ok := c.connPool.(*pool.ConnPool); ok {
+		return p.RTTs()
+	}
+	return nil
+}
+
 func (c *Client) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
 	return c.Pipeline().Pipelined(ctx, fn)
 }
 
+// PipelinedChunked is like Pipelined, but sends the queued commands in
+// pipelines of at most batchSize commands, one after the other. Each
+// chunk gets its own read and write timeouts, so large batches don't have
+// to fit in one timeout. All chunks are sent even if one fails; the
+// commands are returned in the order they were queued, with the error of
+// the first failed command.
+//
+// Retries apply per chunk, so the commands of a chunk that timed out may
+// run more than once, as with Pipelined.
+func (c *Client) PipelinedChunked(
+	ctx context.Context, batchSize int, fn func(Pipeliner) error,
+) ([]Cmder, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("skytable: invalid batch size %d", batchSize)
+	}
+
+	pipe := c.Pipeline()
+	if err := fn(pipe); err != nil {
+		return nil, err
+	}
+	cmds := pipe.DiscardAndReturn()
+
+	var firstErr error
+	for i := 0; i < len(cmds); i += batchSize {
+		end := i + batchSize
+		if end > len(cmds) {
+			end = len(cmds)
+		}
+		if err := c.processPipeline(ctx, cmds[i:end]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return cmds, firstErr
+}
+
 func (c *Client) Pipeline() Pipeliner {
 	pipe := Pipeline{
-		exec: c.processPipeline,
+		exec:   c.processPipeline,
+		encode: c.encodeCmds,
 	}
 	pipe.init()
 	return &pipe
@@ -572,11 +1083,30 @@ type conn struct {
 	cmdable
 	statefulCmdable
 	hooks // TODO: inherit hooks
+
+	// busy is set while a command or pipeline runs, see ErrConnInUse.
+	busy uint32
+	// switched is set once USE ran on the pinned connection, which then
+	// isn't returned to the pool, see Conn.Release.
+	switched uint32
 }
 
 // Conn represents a single Skytable connection rather than a pool of connections.
 // Prefer running commands from Client unless there is a specific need
 // for a continuous single Skytable connection.
+//
+// A Conn takes a connection from the client's pool on its first command
+// and keeps it, with the keyspace or table selected by USE, until Close or
+// Release. If that connection breaks, the command fails and every later
+// one fails with ErrConnBroken instead of silently running on a new
+// connection; IsHealthy checks the connection beforehand.
+//
+// A Conn runs one command or pipeline at a time: those started while
+// another runs fail with ErrConnInUse without being sent.
+//
+// A connection that switched tables with Use is closed by Close and
+// Release instead of being returned to the pool, so that other commands
+// of the client don't run on a table they didn't select.
 type Conn struct {
 	*conn
 }
@@ -587,6 +1117,7 @@ func newConn(opt *Options, connPool pool.Pooler) *Conn {
 			baseClient: baseClient{
 				opt:      opt,
 				connPool: connPool,
+				closed:   new(uint32),
 			},
 		},
 	}
@@ -596,11 +1127,88 @@ func newConn(opt *Options, connPool pool.Pooler) *Conn {
 }
 
 func (c *Conn) Process(ctx context.Context, cmd Cmder) error {
-	return c.hooks.process(ctx, cmd, c.baseClient.process)
+	if !atomic.CompareAndSwapUint32(&c.busy, 0, 1) {
+		cmd.SetErr(ErrConnInUse)
+		return ErrConnInUse
+	}
+	defer atomic.StoreUint32(&c.busy, 0)
+	err := c.hooks.process(ctx, cmd, c.baseClient.process)
+	c.markSwitched(cmd)
+	return err
+}
+
+// markSwitched records that cmd selected another table on the pinned
+// connection.
+func (c *Conn) markSwitched(cmd Cmder) {
+	if cmd.Name() == "use" && cmd.Err() == nil {
+		atomic.StoreUint32(&c.switched, 1)
+	}
+}
+
+// discardSwitched marks the pinned connection bad if it switched tables,
+// so that releasing it closes it rather than returning it to the pool.
+func (c *Conn) discardSwitched(p *pool.StickyConnPool) {
+	if !atomic.CompareAndSwapUint32(&c.switched, 1, 0) || p.Len() == 0 {
+		return
+	}
+	ctx := context.Background()
+	if cn, err := p.Get(ctx); err == nil {
+		p.Remove(ctx, cn, errTableSwitched)
+	}
+}
+
+// IsHealthy reports whether the connection of the Conn answers HEYA. It
+// is false once the connection broke, and takes a connection from the
+// pool if the Conn has none yet.
+func (c *Conn) IsHealthy(ctx context.Context) bool {
+	return c.Heya(ctx, "").Err() == nil
+}
+
+// pin takes the connection of the Conn from the pool now rather than on
+// its first command.
+func (c *Conn) pin(ctx context.Context) error {
+	cn, err := c.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	c.releaseConn(ctx, cn, nil)
+	return nil
+}
+
+// Release returns the connection pinned by the Conn to the client's pool,
+// where other commands can reuse it, instead of closing it; a connection
+// that switched tables with Use is closed. The Conn stays usable and pins
+// a connection again for its next command. Release must not be called
+// while a command runs on the Conn.
+func (c *Conn) Release() error {
+	p, ok := c.connPool.(*pool.StickyConnPool)
+	if !ok {
+		return errors.New("skytable: Conn does not belong to a pool")
+	}
+	c.discardSwitched(p)
+	return p.Release(context.Background())
+}
+
+// Close closes the Conn and returns its connection to the client's pool,
+// or closes the connection if it switched tables with Use.
+func (c *Conn) Close() error {
+	if p, ok := c.connPool.(*pool.StickyConnPool); ok {
+		c.discardSwitched(p)
+	}
+	return c.baseClient.Close()
 }
 
 func (c *Conn) processPipeline(ctx context.Context, cmds []Cmder) error {
-	return c.hooks.processPipeline(ctx, cmds, c.baseClient.processPipeline)
+	if !atomic.CompareAndSwapUint32(&c.busy, 0, 1) {
+		setCmdsErr(cmds, ErrConnInUse)
+		return ErrConnInUse
+	}
+	defer atomic.StoreUint32(&c.busy, 0)
+	err := c.hooks.processPipeline(ctx, cmds, c.baseClient.processPipeline)
+	for _, cmd := range cmds {
+		c.markSwitched(cmd)
+	}
+	return err
 }
 
 func (c *Conn) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
@@ -609,8 +1217,54 @@ func (c *Conn) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder
 
 func (c *Conn) Pipeline() Pipeliner {
 	pipe := Pipeline{
-		exec: c.processPipeline,
+		exec:   c.processPipeline,
+		encode: c.encodeCmds,
 	}
 	pipe.init()
 	return &pipe
 }
+
+// ------------------------------------------------------------------------------
+
+// Session runs commands on a single connection pinned from the client's
+// pool, which saves the connection churn of sequential commands that
+// belong to one logical request. It is the Conn returned by Pinned,
+// restricted to Cmdable, so it shares its behavior, e.g. after Use.
+// Session is not safe for concurrent use.
+type Session struct {
+	cmdable
+	conn *Conn
+}
+
+// Session pins a connection from the pool, like Pinned, and returns a
+// Session that runs all commands on it, or the error of taking the
+// connection. Close must be called to return the connection to the pool.
+func (c *Client) Session(ctx context.Context) (*Session, error) {
+	conn, err := c.pinConn(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	s := &Session{conn: conn}
+	s.cmdable = s.Process
+	return s, nil
+}
+
+func (s *Session) Process(ctx context.Context, cmd Cmder) error {
+	return s.conn.Process(ctx, cmd)
+}
+
+func (s *Session) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return s.conn.Pipelined(ctx, fn)
+}
+
+func (s *Session) Pipeline() Pipeliner {
+	return s.conn.Pipeline()
+}
+
+// Close returns the pinned connection to the pool, like the release func
+// of Pinned.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
@@ -4,7 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -45,6 +52,1431 @@ func TestHookError(t *testing.T) {
 	}
 }
 
+func TestNonIdempotentNotRetriedAfterReadTimeout(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return "" // never reply
+	})
+	opt := srv.options()
+	opt.ReadTimeout = 50 * time.Millisecond
+	opt.MaxRetries = 3
+	opt.MinRetryBackoff = -1
+	opt.MaxRetryBackoff = -1
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.LModPush(ctx, "list", "a").Err(); err == nil {
+		t.Fatalf("got nil, expected a timeout error")
+	}
+	if got := len(srv.Commands()); got != 1 {
+		t.Fatalf("LMOD PUSH was sent %d times, wanted 1", got)
+	}
+
+	if err := rdb.Get(ctx, "key").Err(); err == nil {
+		t.Fatalf("got nil, expected a timeout error")
+	}
+	if got := len(srv.Commands()); got != 1+opt.MaxRetries+1 {
+		t.Fatalf("got %d commands, wanted GET to be sent %d times", got, opt.MaxRetries+1)
+	}
+}
+
+func TestStatusCmdCode(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "SET":
+			return fakeStatus(2)
+		case "LMOD":
+			return fakeStatus(1)
+		default:
+			return fakeStatus(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	tests := []struct {
+		cmd  *skytable.StatusCmd
+		code int64
+		err  error
+	}{
+		{rdb.Update(ctx, "key", "value"), 0, nil},
+		{rdb.Set(ctx, "key", "value"), 2, skytable.OverwriteError},
+		{rdb.LModClear(ctx, "key"), 1, skytable.Nil},
+	}
+	for _, test := range tests {
+		if got := test.cmd.Code(); got != test.code {
+			t.Errorf("%s: got code %d, wanted %d", test.cmd.Name(), got, test.code)
+		}
+		if err := test.cmd.Err(); err != test.err {
+			t.Errorf("%s: got error %v, wanted %v", test.cmd.Name(), err, test.err)
+		}
+	}
+}
+
+func TestFloatRoundTrip(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	a, b := 0.1, 0.2
+	want := a + b
+	if err := rdb.Set(ctx, "key", want).Err(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := rdb.Get(ctx, "key").Float64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Float64bits(got) != math.Float64bits(want) {
+		t.Fatalf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestMGetReplyCountMismatch(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if len(args) == 3 {
+			return fakeArray(fakeString("a"), fakeStatus(1))
+		}
+		// One value short.
+		return fakeArray(fakeString("a"), fakeString("b"))
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	vals, err := rdb.MGet(ctx, "k1", "k2").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vals) != 2 || vals[0] != "a" || vals[1] != nil {
+		t.Fatalf("got %q, wanted [a <nil>]", vals)
+	}
+	m, err := rdb.MGetMap(ctx, "k1", "k2").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 || m["k1"] != "a" {
+		t.Fatalf("got %q, wanted map[k1:a]", m)
+	}
+
+	if err := rdb.MGet(ctx, "k1", "k2", "k3").Err(); !errors.Is(err, skytable.ErrReplyCountMismatch) {
+		t.Fatalf("MGet: got %v, wanted %v", err, skytable.ErrReplyCountMismatch)
+	}
+	if err := rdb.MGetMap(ctx, "k1", "k2", "k3").Err(); !errors.Is(err, skytable.ErrReplyCountMismatch) {
+		t.Fatalf("MGetMap: got %v, wanted %v", err, skytable.ErrReplyCountMismatch)
+	}
+
+	for _, args := range srv.Commands() {
+		if args[0] != "MGET" || len(args) < 3 {
+			t.Fatalf("got %q, wanted keys sent as separate arguments", args)
+		}
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeError("err")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	tests := []struct {
+		send func() skytable.Cmder
+		want []string
+	}{
+		{func() skytable.Cmder { return rdb.Del(ctx, "a", "b") }, []string{"DEL", "a", "b"}},
+		{func() skytable.Cmder { return rdb.Exists(ctx, "a", "b") }, []string{"EXISTS", "a", "b"}},
+		{func() skytable.Cmder { return rdb.MPop(ctx, "a", "b") }, []string{"MPOP", "a", "b"}},
+		{func() skytable.Cmder { return rdb.MPop(ctx, []string{"a", "b"}) }, []string{"MPOP", "a", "b"}},
+		{func() skytable.Cmder { return rdb.MSet(ctx, "a", "1", "b", "2") }, []string{"MSET", "a", "1", "b", "2"}},
+		{func() skytable.Cmder { return rdb.MSet(ctx, map[string]string{"a": "1"}) }, []string{"MSET", "a", "1"}},
+		{func() skytable.Cmder { return rdb.MUpdate(ctx, []interface{}{"a", "1"}) }, []string{"MUPDATE", "a", "1"}},
+		{func() skytable.Cmder { return rdb.SSet(ctx, map[string]interface{}{"a": "1"}) }, []string{"SSET", "a", "1"}},
+		{func() skytable.Cmder { return rdb.SUpdate(ctx, "a", "1") }, []string{"SUPDATE", "a", "1"}},
+		{func() skytable.Cmder { return rdb.SDel(ctx, []string{"a", "b"}) }, []string{"SDEL", "a", "b"}},
+		{func() skytable.Cmder { return rdb.USet(ctx, map[string]string{"a": "1"}) }, []string{"USET", "a", "1"}},
+	}
+	for _, tt := range tests {
+		n := len(srv.Commands())
+		_ = tt.send()
+		cmds := srv.Commands()
+		if len(cmds) != n+1 {
+			t.Fatalf("%s: got %d commands sent, wanted 1", tt.want[0], len(cmds)-n)
+		}
+		if got := cmds[n]; !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("got %q, wanted %q", got, tt.want)
+		}
+	}
+}
+
+func TestCmdIsStatusOK(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "SET":
+			return fakeStatus(0)
+		case "UPDATE":
+			return fakeStatus(1)
+		default:
+			return fakeInt(0)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	set := rdb.Do(ctx, "SET", "key", "value")
+	if err := set.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !set.IsStatusOK() {
+		t.Fatalf("SET: got IsStatusOK false, wanted true")
+	}
+
+	if update := rdb.Do(ctx, "UPDATE", "key", "value"); update.IsStatusOK() {
+		t.Fatalf("UPDATE: got IsStatusOK true for %v", update.Err())
+	}
+
+	dbsize := rdb.Do(ctx, "DBSIZE")
+	if dbsize.IsStatusOK() {
+		t.Fatalf("DBSIZE: got IsStatusOK true, wanted false")
+	}
+	if dbsize.Val() != int64(0) {
+		t.Fatalf("DBSIZE: got %v, wanted 0", dbsize.Val())
+	}
+}
+
+func TestStringCmdBinary(t *testing.T) {
+	blob := "\xff\xfe\x00\nnot utf-8\xc3"
+	srv := newFakeServer(func(args []string) string {
+		return fakeBlob(blob)
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	get := rdb.Get(ctx, "key")
+	b, err := get.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte(blob)) {
+		t.Fatalf("got %q, wanted %q", b, blob)
+	}
+	if get.Val() != blob {
+		t.Fatalf("got %q, wanted %q", get.Val(), blob)
+	}
+
+	// The next reply doesn't overwrite the first one.
+	if err := rdb.Get(ctx, "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte(blob)) {
+		t.Fatalf("got %q after the next read, wanted %q", b, blob)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[1] == "KEYSPACES" {
+			return fakeArray(fakeString("default"), fakeString("system"))
+		}
+		return fakeError("unknown-inspect-query")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	keyspaces, err := rdb.Inspect(ctx, "KEYSPACES").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keyspaces, []string{"default", "system"}) {
+		t.Fatalf("got %q, wanted [default system]", keyspaces)
+	}
+
+	err = rdb.Inspect(ctx, "FUTURE", "arg").Err()
+	if err != skytable.UnknownInspectQueryError {
+		t.Fatalf("got %v, wanted %v", err, skytable.UnknownInspectQueryError)
+	}
+}
+
+func TestInitCommands(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	opt := srv.options()
+	opt.InitCommands = []func(ctx context.Context, cn *skytable.Conn) error{
+		func(ctx context.Context, cn *skytable.Conn) error {
+			return cn.Heya(ctx, "first").Err()
+		},
+		func(ctx context.Context, cn *skytable.Conn) error {
+			return cn.Heya(ctx, "second").Err()
+		},
+	}
+	opt.OnConnect = func(ctx context.Context, cn *skytable.Conn) error {
+		return cn.Heya(ctx, "connected").Err()
+	}
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.Heya(ctx, "command").Err(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, args := range srv.Commands() {
+		got = append(got, args[1])
+	}
+	want := []string{"first", "second", "connected", "command"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestWriteRespectsContextDeadline(t *testing.T) {
+	rdb := skytable.NewClient(&skytable.Options{
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return &badConn{writeDelay: time.Second}, nil
+		},
+		WriteTimeout: -1,
+		MaxRetries:   -1,
+	})
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := rdb.Heya(ctx, "").Err(); err == nil {
+		t.Fatal("got nil, wanted a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("write took %s, wanted it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestServerUnavailable(t *testing.T) {
+	var dials int
+	rdb := skytable.NewClient(&skytable.Options{
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			return &badConn{readErr: io.EOF, writeErr: io.EOF}, nil
+		},
+		MaxRetries:      2,
+		MinRetryBackoff: time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+	})
+	defer rdb.Close()
+
+	err := rdb.Heya(ctx, "").Err()
+	if !errors.Is(err, skytable.ErrServerUnavailable) {
+		t.Fatalf("got %v, wanted ErrServerUnavailable", err)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v, wanted it to wrap io.EOF", err)
+	}
+	if dials != 3 {
+		t.Fatalf("got %d dials, wanted 3", dials)
+	}
+
+	_, err = rdb.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Heya(ctx, "")
+		return nil
+	})
+	if !errors.Is(err, skytable.ErrServerUnavailable) {
+		t.Fatalf("pipeline: got %v, wanted ErrServerUnavailable", err)
+	}
+}
+
+func TestCmdAttempts(t *testing.T) {
+	rdb := skytable.NewClient(&skytable.Options{
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return &badConn{readErr: io.EOF, writeErr: io.EOF}, nil
+		},
+		MaxRetries:      2,
+		MinRetryBackoff: time.Millisecond,
+		MaxRetryBackoff: time.Millisecond,
+	})
+	defer rdb.Close()
+
+	var seen int
+	rdb.AddHook(&hook{
+		afterProcess: func(ctx context.Context, cmd skytable.Cmder) error {
+			seen = cmd.Attempts()
+			return nil
+		},
+	})
+
+	cmd := rdb.Heya(ctx, "")
+	if cmd.Err() == nil {
+		t.Fatal("got nil error")
+	}
+	if n := cmd.Attempts(); n != 3 {
+		t.Fatalf("got %d attempts, wanted 3", n)
+	}
+	if seen != 3 {
+		t.Fatalf("AfterProcess saw %d attempts, wanted 3", seen)
+	}
+
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	opt := srv.options()
+	opt.ReadOnly = true
+	ok := skytable.NewClient(opt)
+	defer ok.Close()
+
+	if n := ok.Heya(ctx, "").Attempts(); n != 1 {
+		t.Fatalf("got %d attempts, wanted 1", n)
+	}
+	if n := ok.Set(ctx, "a", "1").Attempts(); n != 0 {
+		t.Fatalf("got %d attempts for a rejected command, wanted 0", n)
+	}
+}
+
+func TestConnBroken(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "USE":
+			return fakeStatus(0)
+		case "GET":
+			if args[1] == "hangup" {
+				return fakeHangUp
+			}
+			return fakeString("v")
+		}
+		return fakeString("HEY!")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	conn := rdb.Conn()
+	defer conn.Close()
+
+	if err := conn.Use(ctx, "default:t").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !conn.IsHealthy(ctx) {
+		t.Fatal("got IsHealthy false for a working connection")
+	}
+
+	if err := conn.Get(ctx, "hangup").Err(); err == nil {
+		t.Fatal("got nil error from the broken connection")
+	}
+	err := conn.Get(ctx, "a").Err()
+	if !errors.Is(err, skytable.ErrConnBroken) {
+		t.Fatalf("got %v, wanted ErrConnBroken", err)
+	}
+	if conn.IsHealthy(ctx) {
+		t.Fatal("got IsHealthy true for a broken connection")
+	}
+	if n := srv.Dials(); n != 1 {
+		t.Fatalf("got %d dials, wanted the Conn not to redial", n)
+	}
+}
+
+func TestConnInUse(t *testing.T) {
+	started := make(chan struct{})
+	srv := newFakeServer(func(args []string) string {
+		if args[1] == "slow" {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+		}
+		return fakeString(args[1])
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	conn := rdb.Conn()
+	defer conn.Close()
+
+	done := make(chan *skytable.StringCmd)
+	go func() {
+		done <- conn.Get(ctx, "slow")
+	}()
+	<-started
+
+	if err := conn.Get(ctx, "a").Err(); err != skytable.ErrConnInUse {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrConnInUse)
+	}
+	_, err := conn.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Get(ctx, "b")
+		return nil
+	})
+	if err != skytable.ErrConnInUse {
+		t.Fatalf("pipeline: got %v, wanted %v", err, skytable.ErrConnInUse)
+	}
+
+	if val, err := (<-done).Result(); err != nil || val != "slow" {
+		t.Fatalf("got %q, %v, wanted the first command unaffected", val, err)
+	}
+	if val, err := conn.Get(ctx, "c").Result(); err != nil || val != "c" {
+		t.Fatalf("got %q, %v after the first command, wanted c", val, err)
+	}
+	if got := srv.Commands(); len(got) != 2 {
+		t.Fatalf("got %q, wanted the rejected commands not to be sent", got)
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString(args[1])
+	})
+
+	var counts []int
+	errFrame := errors.New("frame rejected")
+	opt := srv.options()
+	opt.FrameWriter = func(wr *skytable.ProtoWriter, count int) error {
+		counts = append(counts, count)
+		if count == 2 {
+			return errFrame
+		}
+		return wr.WriteMetaFrame(count)
+	}
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if val, err := rdb.Get(ctx, "a").Result(); err != nil || val != "a" {
+		t.Fatalf("got %q, %v, wanted a", val, err)
+	}
+	cmds, err := rdb.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Get(ctx, "b")
+		pipe.Get(ctx, "c")
+		pipe.Get(ctx, "d")
+		return nil
+	})
+	if err != nil || cmds[2].(*skytable.StringCmd).Val() != "d" {
+		t.Fatalf("got %v, %v", cmds, err)
+	}
+	_, err = rdb.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Get(ctx, "e")
+		pipe.Get(ctx, "f")
+		return nil
+	})
+	if !errors.Is(err, errFrame) {
+		t.Fatalf("got %v, wanted the FrameWriter error", err)
+	}
+
+	if want := []int{1, 3, 2}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("got counts %v, wanted %v", counts, want)
+	}
+	if n := len(srv.Commands()); n != 4 {
+		t.Fatalf("server got %d commands, wanted 4", n)
+	}
+}
+
+func TestVerifyProtocol(t *testing.T) {
+	tests := []struct {
+		protover string
+		wantErr  bool
+	}{
+		{"%3\n1.2\n", false},
+		{"%3\n2.0\n", true},
+		{"%3\n0.8\n", true},
+		{fakeError("Unknown action"), false},
+	}
+	for _, tt := range tests {
+		srv := newFakeServer(func(args []string) string {
+			if args[0] == "SYS" {
+				return tt.protover
+			}
+			return fakeString("v")
+		})
+
+		opt := srv.options()
+		opt.VerifyProtocol = true
+		rdb := skytable.NewClient(opt)
+
+		err := rdb.Get(ctx, "a").Err()
+		if tt.wantErr != errors.Is(err, skytable.ErrUnsupportedProtocol) {
+			t.Fatalf("%q: got %v", tt.protover, err)
+		}
+		want := [][]string{{"SYS", "INFO", "protover"}}
+		if !tt.wantErr {
+			want = append(want, []string{"GET", "a"})
+		}
+		if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: got %q, wanted %q", tt.protover, got, want)
+		}
+		rdb.Close()
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[1] == "slow" {
+			time.Sleep(time.Second)
+		}
+		return fakeString(args[1])
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if rdb.Context() != context.Background() {
+		t.Fatal("got a non-background base context")
+	}
+
+	clientCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	limited := rdb.WithContext(clientCtx)
+	if limited.Context() != clientCtx {
+		t.Fatal("WithContext didn't set the base context")
+	}
+
+	if err := limited.Heya(ctx, "fast").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := limited.Heya(ctx, "slow").Err(); err == nil {
+		t.Fatal("got nil, wanted the client context deadline to apply")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("command took %s, wanted it to stop at the client deadline", elapsed)
+	}
+
+	// The original client is unaffected.
+	<-clientCtx.Done()
+	if err := rdb.Heya(ctx, "fast").Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitCommandsError(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	opt := srv.options()
+	initErr := errors.New("init failed")
+	opt.InitCommands = []func(ctx context.Context, cn *skytable.Conn) error{
+		func(ctx context.Context, cn *skytable.Conn) error {
+			return initErr
+		},
+		func(ctx context.Context, cn *skytable.Conn) error {
+			t.Fatal("init command run after an error")
+			return nil
+		},
+	}
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.Heya(ctx, "").Err(); err != initErr {
+		t.Fatalf("got %v, wanted %v", err, initErr)
+	}
+	if n := len(srv.Commands()); n != 0 {
+		t.Fatalf("got %d commands, wanted none", n)
+	}
+}
+
+func TestProtectedObject(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeError("err-protected-object")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	err := rdb.DropTable(ctx, "system:auth").Err()
+	if err != skytable.ErrProtectedObject {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrProtectedObject)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+	opt := srv.options()
+	opt.ReadOnly = true
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.Get(ctx, "key").Err(); err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+
+	writes := []skytable.Cmder{
+		rdb.Set(ctx, "key", "value"),
+		rdb.Del(ctx, "key"),
+		rdb.LModClear(ctx, "list"),
+		rdb.FlushDB(ctx, ""),
+		rdb.DropTable(ctx, "ks:table"),
+		rdb.AddUser(ctx, "user"),
+	}
+	for _, cmd := range writes {
+		if cmd.Err() != skytable.ErrReadOnly {
+			t.Fatalf("%s: got %v, wanted %v", cmd.FullName(), cmd.Err(), skytable.ErrReadOnly)
+		}
+	}
+
+	_, err := rdb.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Get(ctx, "key")
+		pipe.Update(ctx, "key", "value")
+		return nil
+	})
+	if err != skytable.ErrReadOnly {
+		t.Fatalf("pipeline: got %v, wanted %v", err, skytable.ErrReadOnly)
+	}
+
+	want := [][]string{{"GET", "key"}}
+	if got := srv.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestAuthStatus(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  skytable.AuthStatus
+	}{
+		{fakeError("err-auth-disabled"), skytable.AuthStatus{}},
+		{fakeStatus(11), skytable.AuthStatus{Enabled: true}},
+		{fakeString("root"), skytable.AuthStatus{Enabled: true, Claimed: true}},
+	}
+	for _, tt := range tests {
+		srv := newFakeServer(func(args []string) string {
+			return tt.reply
+		})
+		rdb := skytable.NewClient(srv.options())
+
+		got, err := rdb.AuthStatus(ctx).Result()
+		if err != nil {
+			t.Fatalf("%q: %v", tt.reply, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%q: got %+v, wanted %+v", tt.reply, got, tt.want)
+		}
+		_ = rdb.Close()
+	}
+}
+
+func TestDeniedCommands(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+	opt := srv.options()
+	opt.DeniedCommands = []string{"FLUSHDB"}
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.FlushDB(ctx, "").Err(); !errors.Is(err, skytable.ErrCommandNotAllowed) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrCommandNotAllowed)
+	}
+	if err := rdb.Get(ctx, "key").Err(); err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+	if n := len(srv.Commands()); n != 1 {
+		t.Fatalf("got %d commands, wanted 1", n)
+	}
+}
+
+func TestAllowedCommands(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+	opt := srv.options()
+	opt.AllowedCommands = []string{"get"}
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.Get(ctx, "key").Err(); err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+	if err := rdb.Set(ctx, "key", "value").Err(); !errors.Is(err, skytable.ErrCommandNotAllowed) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrCommandNotAllowed)
+	}
+}
+
+func TestBytesTransferred(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var written, read int64
+	rdb.AddHook(&hook{
+		afterProcess: func(ctx context.Context, cmd skytable.Cmder) error {
+			written, read = cmd.BytesWritten(), cmd.BytesRead()
+			return nil
+		},
+	})
+
+	if err := rdb.Set(ctx, "key", "value").Err(); err != nil {
+		t.Fatal(err)
+	}
+	// ~3\n 3\nSET\n 3\nkey\n 5\nvalue\n
+	if want := int64(3 + 6 + 6 + 8); written != want {
+		t.Fatalf("got %d bytes written, wanted %d", written, want)
+	}
+	// !1\n0\n
+	if want := int64(5); read != want {
+		t.Fatalf("got %d bytes read, wanted %d", read, want)
+	}
+}
+
+func TestSession(t *testing.T) {
+	var mu sync.Mutex
+	var conns int
+	srv := newFakeServerPerConn(func() func(args []string) string {
+		mu.Lock()
+		conns++
+		id := fmt.Sprint(conns)
+		mu.Unlock()
+		return func(args []string) string {
+			return fakeString(id)
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	sess, err := rdb.Session(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := sess.Get(ctx, "key").Val()
+	for i := 0; i < 3; i++ {
+		if got := sess.Get(ctx, "key").Val(); got != first {
+			t.Fatalf("command %d ran on conn %q, wanted %q", i, got, first)
+		}
+		// The client can't reuse the connection pinned by the session.
+		if got := rdb.Get(ctx, "key").Val(); got == first {
+			t.Fatalf("client ran on the session's conn %q", got)
+		}
+	}
+
+	if err := sess.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := rdb.PoolStats().TotalConns; n != 2 {
+		t.Fatalf("got %d conns, wanted 2", n)
+	}
+	if n := rdb.PoolStats().IdleConns; n != 2 {
+		t.Fatalf("got %d idle conns, wanted 2", n)
+	}
+}
+
+func TestReplyAttrs(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return "|1\n+4\nnode\n+2\nn1\n" + fakeString("value")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	get := rdb.Get(ctx, "key")
+	if get.Val() != "value" {
+		t.Fatalf("got %q, %v, wanted value", get.Val(), get.Err())
+	}
+	if attrs := get.Attrs(); len(attrs) != 1 || attrs["node"] != "n1" {
+		t.Fatalf("got attrs %v", attrs)
+	}
+	// The next reply on the connection is read in sync.
+	if val := rdb.Get(ctx, "key").Val(); val != "value" {
+		t.Fatalf("got %q, wanted value", val)
+	}
+}
+
+func TestCmdMeta(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var got interface{}
+	rdb.AddHook(&hook{
+		afterProcess: func(ctx context.Context, cmd skytable.Cmder) error {
+			got = cmd.Meta("order")
+			return nil
+		},
+	})
+
+	cmd := skytable.NewStatusCmd(ctx, "SET", "key", "value")
+	cmd.SetMeta("order", 42)
+	if err := rdb.Process(ctx, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if got != 42 {
+		t.Fatalf("got meta %v in hook, wanted 42", got)
+	}
+	if want := [][]string{{"SET", "key", "value"}}; !reflect.DeepEqual(srv.Commands(), want) {
+		t.Fatalf("server got %q, wanted %q", srv.Commands(), want)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	rdb := skytable.NewClient(&skytable.Options{Addr: skytableAddr})
+	var closes int
+	rdb.SetOnClose(func() error {
+		closes++
+		return nil
+	})
+	clone := rdb.WithTimeout(time.Second)
+
+	for i, c := range []*skytable.Client{rdb, rdb, clone} {
+		if err := c.Close(); err != nil {
+			t.Fatalf("Close %d: %v", i, err)
+		}
+	}
+	if closes != 1 {
+		t.Fatalf("onClose ran %d times, wanted 1", closes)
+	}
+
+	conn := skytable.NewClient(&skytable.Options{Addr: skytableAddr}).Conn()
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatalf("second Conn.Close: %v", err)
+	}
+}
+
+func TestProcessWithHooks(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var order []string
+	rdb.AddHook(&hook{
+		beforeProcess: func(ctx context.Context, cmd skytable.Cmder) (context.Context, error) {
+			order = append(order, "global "+cmd.Name())
+			return ctx, nil
+		},
+	})
+	oneOff := &hook{
+		beforeProcess: func(ctx context.Context, cmd skytable.Cmder) (context.Context, error) {
+			order = append(order, "one-off "+cmd.Name())
+			return ctx, nil
+		},
+	}
+
+	cmd := skytable.NewStatusCmd(ctx, "SET", "key", "value")
+	if err := rdb.ProcessWithHooks(ctx, cmd, oneOff); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Get(ctx, "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"global set", "one-off set", "global get"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("got %q, wanted %q", order, want)
+	}
+}
+
+func TestConnRelease(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("HEY!")
+	})
+	client := skytable.NewClient(srv.options())
+	defer client.Close()
+
+	conn := client.Conn()
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := conn.Heya(ctx, "").Err(); err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.Release(); err != nil {
+			t.Fatal(err)
+		}
+		stats := client.PoolStats()
+		if stats.TotalConns != 1 || stats.IdleConns != 1 {
+			t.Fatalf("got %d conns, %d idle after Release, wanted 1, 1", stats.TotalConns, stats.IdleConns)
+		}
+
+		// The released connection is reused by the client.
+		if err := client.Heya(ctx, "").Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.Dials(); n != 1 {
+		t.Fatalf("got %d dials, wanted 1", n)
+	}
+}
+
+func TestConnReleaseAfterUse(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[0] == "USE" {
+			return fakeStatus(0)
+		}
+		return fakeString("HEY!")
+	})
+	client := skytable.NewClient(srv.options())
+	defer client.Close()
+
+	tests := []struct {
+		name    string
+		release func(*skytable.Conn)
+	}{
+		{"Release", func(conn *skytable.Conn) { _ = conn.Release() }},
+		{"Close", func(conn *skytable.Conn) { _ = conn.Close() }},
+		{"Pinned", nil},
+	}
+	for _, tt := range tests {
+		conn, release := client.Pinned(ctx)
+		if tt.release != nil {
+			release = func() { tt.release(conn) }
+		}
+		if err := conn.Use(ctx, "ks:other").Err(); err != nil {
+			t.Fatal(err)
+		}
+		release()
+		if n := client.PoolStats().TotalConns; n != 0 {
+			t.Fatalf("%s: got %d conns after USE, wanted the connection closed", tt.name, n)
+		}
+		_ = conn.Close()
+	}
+
+	// A Session is a pinned Conn and behaves the same.
+	sess, err := client.Session(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.Use(ctx, "ks:other").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.PoolStats().TotalConns; n != 0 {
+		t.Fatalf("Session: got %d conns after USE, wanted the connection closed", n)
+	}
+
+	// A Conn that didn't switch tables still returns its connection.
+	conn := client.Conn()
+	if err := conn.Heya(ctx, "").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := client.PoolStats(); stats.TotalConns != 1 || stats.IdleConns != 1 {
+		t.Fatalf("got %d conns, %d idle after Close, wanted 1, 1", stats.TotalConns, stats.IdleConns)
+	}
+}
+
+func TestStringSliceMissingElements(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "MPOP":
+			return fakeArray(fakeString("a"), fakeStatus(1), fakeString(""), fakeError("err-protected-object"))
+		default:
+			return fakeString("HEY!")
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	cmd := rdb.MPop(ctx, "a", "missing", "empty", "protected")
+	if err := cmd.Err(); err != skytable.ErrProtectedObject {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrProtectedObject)
+	}
+	if want := []string{"a", "", "", ""}; !reflect.DeepEqual(cmd.Val(), want) {
+		t.Fatalf("got %q, wanted %q", cmd.Val(), want)
+	}
+	for i, want := range []bool{true, false, true, false} {
+		if got := cmd.Present(i); got != want {
+			t.Fatalf("Present(%d) = %v, wanted %v", i, got, want)
+		}
+	}
+
+	// The whole array was read, so the connection is still in sync.
+	if val := rdb.Heya(ctx, "").Val(); val != "HEY!" {
+		t.Fatalf("got %q after the array, wanted HEY!", val)
+	}
+}
+
+func TestMaxReplySize(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[1] == "huge" {
+			return "&1000000000\n"
+		}
+		return fakeArray(fakeString("a"))
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	err := rdb.LGet(ctx, "huge").Err()
+	if !errors.Is(err, skytable.ErrReplyTooLarge) {
+		t.Fatalf("got %v, wanted %v", err, skytable.ErrReplyTooLarge)
+	}
+
+	// The connection is out of sync and was closed.
+	if val, err := rdb.LGet(ctx, "list").Result(); err != nil || !reflect.DeepEqual(val, []string{"a"}) {
+		t.Fatalf("got %q, %v, wanted [a]", val, err)
+	}
+	if n := srv.Dials(); n != 2 {
+		t.Fatalf("got %d dials, wanted 2", n)
+	}
+}
+
+func TestStringSliceAccessors(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		if args[1] == "empty" {
+			return fakeArray()
+		}
+		return fakeArray(fakeString("a"), fakeStatus(1), fakeString("b"))
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	cmd := rdb.LGet(ctx, "list")
+	if err := cmd.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n := cmd.Len(); n != 3 {
+		t.Fatalf("got Len %d, wanted 3", n)
+	}
+	tests := []struct {
+		i    int
+		want string
+		ok   bool
+	}{
+		{0, "a", true},
+		{1, "", false},
+		{2, "b", true},
+		{3, "", false},
+		{-1, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := cmd.At(tt.i); got != tt.want || ok != tt.ok {
+			t.Fatalf("At(%d) = %q, %v, wanted %q, %v", tt.i, got, ok, tt.want, tt.ok)
+		}
+	}
+	if !cmd.Contains("b") || cmd.Contains("c") || cmd.Contains("") {
+		t.Fatal("got Contains b false, or c or the missing element true")
+	}
+
+	empty := rdb.LGet(ctx, "empty")
+	if err := empty.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := empty.At(0); ok || empty.Len() != 0 || empty.Contains("") {
+		t.Fatalf("got %q, wanted an empty result", empty.Val())
+	}
+}
+
+func TestFire(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "HEYA":
+			return fakeString("HEY!")
+		case "SYS":
+			return fakeArray(fakeString("a"), fakeStatus(1))
+		default:
+			return fakeError("Unknown action")
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	for _, args := range [][]interface{}{{"HEYA"}, {"SYS", "INFO"}, {"NOPE"}} {
+		if err := rdb.Fire(ctx, args...); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if val := rdb.Heya(ctx, "").Val(); val != "HEY!" {
+		t.Fatalf("got %q after Fire, wanted HEY!", val)
+	}
+	if n := srv.Dials(); n != 1 {
+		t.Fatalf("got %d dials, wanted 1", n)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	conn, release := rdb.Pinned(ctx)
+	if n := rdb.PoolStats().TotalConns; n != 1 {
+		t.Fatalf("got %d conns after Pinned, wanted 1", n)
+	}
+
+	if err := conn.Set(ctx, "key", "value").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := conn.Get(ctx, "key").Result(); err != nil || val != "value" {
+		t.Fatalf("got %q, %v, wanted value", val, err)
+	}
+
+	release()
+	release()
+	if stats := rdb.PoolStats(); stats.TotalConns != 1 || stats.IdleConns != 1 {
+		t.Fatalf("got %d conns, %d idle after release, wanted 1, 1", stats.TotalConns, stats.IdleConns)
+	}
+	if n := srv.Dials(); n != 1 {
+		t.Fatalf("got %d dials, wanted 1", n)
+	}
+}
+
+func TestAutoSelectContainer(t *testing.T) {
+	for _, auto := range []bool{false, true} {
+		var gets int
+		srv := newFakeServer(func(args []string) string {
+			switch args[0] {
+			case "USE":
+				return fakeStatus(0)
+			default:
+				gets++
+				if gets == 1 {
+					return fakeError("default-container-unset")
+				}
+				return fakeString("value")
+			}
+		})
+		opt := srv.options()
+		opt.Table = "ks:table"
+		opt.AutoSelectContainer = auto
+
+		rdb := skytable.NewClient(opt)
+
+		val, err := rdb.Get(ctx, "key").Result()
+		if !auto {
+			if err != skytable.ErrDefaultContainerUnset {
+				t.Fatalf("got %v, wanted %v", err, skytable.ErrDefaultContainerUnset)
+			}
+		} else {
+			if err != nil || val != "value" {
+				t.Fatalf("got %q, %v, wanted the GET to be retried", val, err)
+			}
+			var got []string
+			for _, args := range srv.Commands() {
+				got = append(got, strings.Join(args, " "))
+			}
+			want := []string{"USE ks:table", "GET key", "USE ks:table", "GET key"}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %q, wanted %q", got, want)
+			}
+		}
+		if srv.Dials() != 1 {
+			t.Fatalf("got %d dials, wanted 1", srv.Dials())
+		}
+
+		_ = rdb.Close()
+	}
+}
+
+func TestConnRTTs(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		time.Sleep(5 * time.Millisecond)
+		if args[1] == "missing" {
+			return fakeStatus(1)
+		}
+		return fakeString("v")
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	if rtts := rdb.ConnRTTs(); len(rtts) != 0 {
+		t.Fatalf("got %v before any command, wanted none", rtts)
+	}
+	for _, key := range []string{"a", "missing", "b"} {
+		_ = rdb.Get(ctx, key).Err()
+	}
+	rtts := rdb.ConnRTTs()
+	if len(rtts) != 1 || rtts[0] < 5*time.Millisecond || rtts[0] > time.Second {
+		t.Fatalf("got %v, wanted one RTT of about 5ms", rtts)
+	}
+}
+
+func TestAutoCreateOnMissing(t *testing.T) {
+	var mu sync.Mutex
+	exists := true
+	srv := newFakeServer(func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case args[0] == "CREATE":
+			exists = true
+			return fakeStatus(0)
+		case !exists:
+			return fakeError("container-not-found")
+		case args[0] == "USE":
+			return fakeStatus(0)
+		}
+		return fakeString("value")
+	})
+
+	admin := skytable.NewClient(srv.options())
+	defer admin.Close()
+
+	var created []string
+	opt := srv.options()
+	opt.Table = "ks:table"
+	opt.AutoCreateOnMissing = func(ctx context.Context, entity string) error {
+		created = append(created, entity)
+		return admin.CreateTable(ctx, entity, "keymap", []string{"str", "str"}).Err()
+	}
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	if err := rdb.Get(ctx, "key").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the table: the next GET recreates it and is resent.
+	mu.Lock()
+	exists = false
+	mu.Unlock()
+	val, err := rdb.Get(ctx, "key").Result()
+	if err != nil || val != "value" {
+		t.Fatalf("got %q, %v, wanted the GET to be retried", val, err)
+	}
+	if !reflect.DeepEqual(created, []string{"ks:table"}) {
+		t.Fatalf("got %q, wanted ks:table created once", created)
+	}
+
+	// Commands that name another entity keep the error.
+	mu.Lock()
+	exists = false
+	mu.Unlock()
+	if err := rdb.DbSize(ctx, "ks:other").Err(); err != skytable.ContainerNotFoundError {
+		t.Fatalf("got %v, wanted %v", err, skytable.ContainerNotFoundError)
+	}
+	if len(created) != 1 {
+		t.Fatalf("got %q, wanted DBSIZE not to recreate anything", created)
+	}
+}
+
+type csvRecord []string
+
+func (r *csvRecord) ScanSkytable(src interface{}) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("can't scan %T into csvRecord", src)
+	}
+	*r = strings.Split(s, ",")
+	return nil
+}
+
+func TestScanner(t *testing.T) {
+	srv := newFakeServer(func(args []string) string {
+		switch args[0] {
+		case "GET":
+			return fakeString("a,b,c")
+		default:
+			return fakeArray(fakeString("d,e"), fakeInt(42), fakeStatus(1))
+		}
+	})
+
+	rdb := skytable.NewClient(srv.options())
+	defer rdb.Close()
+
+	var rec csvRecord
+	if err := rdb.Get(ctx, "key").Scan(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec, csvRecord{"a", "b", "c"}) {
+		t.Fatalf("got %q, wanted [a b c]", rec)
+	}
+
+	var n int
+	missing := "untouched"
+	if err := rdb.MGet(ctx, "k1", "k2", "k3").Scan(&rec, &n, &missing); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec, csvRecord{"d", "e"}) || n != 42 || missing != "untouched" {
+		t.Fatalf("got %q, %d, %q", rec, n, missing)
+	}
+}
+
+func TestProcessCanceledBeforeDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	srv := newFakeServer(func(args []string) string {
+		<-release
+		return fakeStatus(0)
+	})
+	opt := srv.options()
+	opt.ReadTimeout = 5 * time.Second
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	// The deadline is within the read timeout, but the command must stop
+	// as soon as ctx is canceled, not when the deadline expires.
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := rdb.Heya(ctx, "").Err()
+	if err != context.Canceled {
+		t.Fatalf("got %v, wanted %v", err, context.Canceled)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("returned after %s", d)
+	}
+}
+
+func BenchmarkProcessWithDeadline(b *testing.B) {
+	srv := newFakeServer(func(args []string) string {
+		return fakeString("value")
+	})
+	opt := srv.options()
+	opt.PoolSize = 64
+
+	rdb := skytable.NewClient(opt)
+	defer rdb.Close()
+
+	var peak int64
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				if n := int64(runtime.NumGoroutine()); n > peak {
+					peak = n
+				}
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			err := rdb.Get(ctx, "key").Err()
+			cancel()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.StopTimer()
+
+	close(stop)
+	<-sampled
+	b.ReportMetric(float64(peak), "peak-goroutines")
+}
+
 // ------------------------------------------------------------------------------
 
 var _ = Describe("Client", func() {
@@ -0,0 +1,207 @@
+// Package skytabletest provides an in-memory stand-in for a Skytable
+// keymap table, for testing code that depends on skytable.KV.
+package skytabletest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/satvik007/skytable-go"
+)
+
+// FakeKV is an in-memory skytable.KV. It follows the semantics of a
+// keymap table: Set fails with skytable.OverwriteError for an existing
+// key, Get and Update fail with skytable.Nil for a missing one, and MSet
+// only sets the keys that don't exist yet. It is safe for concurrent use.
+//
+// Keys and values are stored as strings; booleans are stored as "1" and
+// "0" like the client sends them and other types are formatted with
+// fmt.Sprint.
+type FakeKV struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+var _ skytable.KV = (*FakeKV)(nil)
+
+// NewFakeKV returns an empty FakeKV.
+func NewFakeKV() *FakeKV {
+	return &FakeKV{m: make(map[string]string)}
+}
+
+func (kv *FakeKV) Get(ctx context.Context, key string) *skytable.StringCmd {
+	cmd := skytable.NewStringCmd(ctx, "GET", key)
+
+	kv.mu.Lock()
+	val, ok := kv.m[key]
+	kv.mu.Unlock()
+
+	if !ok {
+		cmd.SetErr(skytable.Nil)
+		return cmd
+	}
+	cmd.SetVal(val)
+	return cmd
+}
+
+func (kv *FakeKV) Set(ctx context.Context, key interface{}, value interface{}) *skytable.StatusCmd {
+	cmd := skytable.NewStatusCmd(ctx, "SET", key, value)
+	k := toString(key)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if _, ok := kv.m[k]; ok {
+		cmd.SetVal(2)
+		cmd.SetErr(skytable.OverwriteError)
+		return cmd
+	}
+	kv.m[k] = toString(value)
+	return cmd
+}
+
+func (kv *FakeKV) Update(ctx context.Context, key interface{}, value interface{}) *skytable.StatusCmd {
+	cmd := skytable.NewStatusCmd(ctx, "UPDATE", key, value)
+	k := toString(key)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	if _, ok := kv.m[k]; !ok {
+		cmd.SetVal(1)
+		cmd.SetErr(skytable.Nil)
+		return cmd
+	}
+	kv.m[k] = toString(value)
+	return cmd
+}
+
+func (kv *FakeKV) Del(ctx context.Context, keys ...string) *skytable.IntCmd {
+	cmd := skytable.NewIntCmd(ctx, stringArgs("DEL", keys)...)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var n int64
+	for _, key := range keys {
+		if _, ok := kv.m[key]; ok {
+			delete(kv.m, key)
+			n++
+		}
+	}
+	cmd.SetVal(n)
+	return cmd
+}
+
+func (kv *FakeKV) Exists(ctx context.Context, keys ...string) *skytable.IntCmd {
+	cmd := skytable.NewIntCmd(ctx, stringArgs("EXISTS", keys)...)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var n int64
+	for _, key := range keys {
+		if _, ok := kv.m[key]; ok {
+			n++
+		}
+	}
+	cmd.SetVal(n)
+	return cmd
+}
+
+// MGet returns the values of keys, with nil for the missing ones.
+func (kv *FakeKV) MGet(ctx context.Context, keys ...interface{}) *skytable.SliceCmd {
+	keys = flatten(keys)
+	cmd := skytable.NewSliceCmd(ctx, append([]interface{}{"MGET"}, keys...)...)
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	vals := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if val, ok := kv.m[toString(key)]; ok {
+			vals[i] = val
+		}
+	}
+	cmd.SetVal(vals)
+	return cmd
+}
+
+// MSet sets the keys that don't exist yet and returns how many it set.
+func (kv *FakeKV) MSet(ctx context.Context, keyValuePairs ...interface{}) *skytable.IntCmd {
+	keyValuePairs = flatten(keyValuePairs)
+	cmd := skytable.NewIntCmd(ctx, append([]interface{}{"MSET"}, keyValuePairs...)...)
+	if len(keyValuePairs)%2 != 0 {
+		cmd.SetErr(fmt.Errorf("skytabletest: MSET got %d arguments, wanted key-value pairs",
+			len(keyValuePairs)))
+		return cmd
+	}
+
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+
+	var n int64
+	for i := 0; i < len(keyValuePairs); i += 2 {
+		key := toString(keyValuePairs[i])
+		if _, ok := kv.m[key]; ok {
+			continue
+		}
+		kv.m[key] = toString(keyValuePairs[i+1])
+		n++
+	}
+	cmd.SetVal(n)
+	return cmd
+}
+
+func stringArgs(name string, keys []string) []interface{} {
+	args := make([]interface{}, 0, 1+len(keys))
+	args = append(args, name)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	return args
+}
+
+// flatten expands a single slice or map argument like the client does.
+func flatten(args []interface{}) []interface{} {
+	if len(args) != 1 {
+		return args
+	}
+	switch arg := args[0].(type) {
+	case []string:
+		return stringArgs("", arg)[1:]
+	case []interface{}:
+		return arg
+	case map[string]interface{}:
+		out := make([]interface{}, 0, 2*len(arg))
+		for k, v := range arg {
+			out = append(out, k, v)
+		}
+		return out
+	case map[string]string:
+		out := make([]interface{}, 0, 2*len(arg))
+		for k, v := range arg {
+			out = append(out, k, v)
+		}
+		return out
+	default:
+		return args
+	}
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
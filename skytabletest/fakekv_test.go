@@ -0,0 +1,81 @@
+package skytabletest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/satvik007/skytable-go"
+	"github.com/satvik007/skytable-go/skytabletest"
+)
+
+var ctx = context.Background()
+
+var errTaken = errors.New("username taken")
+
+// register is application code written against skytable.KV: it claims a
+// username for an email address and keeps a reverse index.
+func register(ctx context.Context, kv skytable.KV, username, email string) error {
+	if err := kv.Set(ctx, "user:"+username, email).Err(); err != nil {
+		if err == skytable.OverwriteError {
+			return errTaken
+		}
+		return err
+	}
+	return kv.Set(ctx, "email:"+email, username).Err()
+}
+
+func TestRegisterWithFakeKV(t *testing.T) {
+	kv := skytabletest.NewFakeKV()
+
+	if err := register(ctx, kv, "ann", "ann@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := register(ctx, kv, "ann", "other@example.com"); err != errTaken {
+		t.Fatalf("got %v, wanted errTaken", err)
+	}
+
+	got, err := kv.Get(ctx, "email:ann@example.com").Result()
+	if err != nil || got != "ann" {
+		t.Fatalf("got %q, %v, wanted ann, <nil>", got, err)
+	}
+	if n := kv.Exists(ctx, "email:other@example.com").Val(); n != 0 {
+		t.Fatal("the failed registration wrote its reverse index")
+	}
+}
+
+func TestFakeKV(t *testing.T) {
+	kv := skytabletest.NewFakeKV()
+
+	if err := kv.Get(ctx, "a").Err(); err != skytable.Nil {
+		t.Fatalf("Get: got %v, wanted Nil", err)
+	}
+	if err := kv.Update(ctx, "a", 1).Err(); err != skytable.Nil {
+		t.Fatalf("Update: got %v, wanted Nil", err)
+	}
+	if n := kv.MSet(ctx, "a", 1, "b", true).Val(); n != 2 {
+		t.Fatalf("MSet: got %d, wanted 2", n)
+	}
+	if n := kv.MSet(ctx, map[string]interface{}{"b": "x", "c": "3"}).Val(); n != 1 {
+		t.Fatalf("MSet with an existing key: got %d, wanted 1", n)
+	}
+	if err := kv.Update(ctx, "a", 2).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	vals := kv.MGet(ctx, []string{"a", "b", "c", "d"}).Val()
+	if want := []interface{}{"2", "1", "3", nil}; !reflect.DeepEqual(vals, want) {
+		t.Fatalf("MGet: got %v, wanted %v", vals, want)
+	}
+
+	if n := kv.Del(ctx, "a", "d").Val(); n != 1 {
+		t.Fatalf("Del: got %d, wanted 1", n)
+	}
+	if n := kv.Exists(ctx, "a", "b", "c").Val(); n != 2 {
+		t.Fatalf("Exists: got %d, wanted 2", n)
+	}
+	if err := kv.MSet(ctx, "a").Err(); err == nil {
+		t.Fatal("MSet with an odd argument count: got nil error")
+	}
+}
@@ -0,0 +1,78 @@
+package skytable
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	"github.com/satvik007/skytable-go/internal/proto"
+)
+
+// Transport sends encoded queries and returns the encoded responses. It
+// is a lower level interception point than Hook: a Transport sees the
+// bytes on the wire and can answer without a server, e.g. in tests, or
+// forward them, e.g. to log or proxy them.
+//
+// When Options.Transport is set, the client doesn't use its connection
+// pool at all, so connection setup like AUTH and USE is not sent.
+type Transport interface {
+	// RoundTrip sends req, a query in Skyhash format (a meta frame
+	// followed by one or more commands), and returns the complete
+	// response, including its meta frame.
+	RoundTrip(ctx context.Context, req []byte) ([]byte, error)
+}
+
+// ReadResponse reads one complete response, a meta frame followed by its
+// replies, from rd and returns its bytes. It reads nothing past the
+// response, so a Transport forwarding queries to a server can use it to
+// delimit the responses on its connection.
+func ReadResponse(rd *bufio.Reader) ([]byte, error) {
+	src := &recordingReader{rd: rd}
+	prd := proto.NewReader(src)
+
+	n, err := prd.ReadMetaFrame()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		if err := prd.DiscardNext(); err != nil {
+			return nil, err
+		}
+	}
+	return src.buf.Bytes(), nil
+}
+
+// recordingReader returns one byte per Read, so a bufio.Reader on top of
+// it never reads ahead, and records the bytes read.
+type recordingReader struct {
+	rd  *bufio.Reader
+	buf bytes.Buffer
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	b, err := r.rd.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	p[0] = b
+	r.buf.WriteByte(b)
+	return 1, nil
+}
+
+// transportRoundTrip sends cmds through Options.Transport and reads their
+// replies.
+func (c *baseClient) transportRoundTrip(ctx context.Context, cmds []Cmder) error {
+	var buf bytes.Buffer
+	if err := c.writeCmds(proto.NewWriter(&buf), cmds); err != nil {
+		return err
+	}
+
+	resp, err := c.opt.Transport.RoundTrip(ctx, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return pipelineReadCmds(proto.NewReader(bytes.NewReader(resp)), cmds)
+}
@@ -0,0 +1,105 @@
+package skytable_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/satvik007/skytable-go"
+)
+
+type transportFunc func(ctx context.Context, req []byte) ([]byte, error)
+
+func (fn transportFunc) RoundTrip(ctx context.Context, req []byte) ([]byte, error) {
+	return fn(ctx, req)
+}
+
+func TestTransport(t *testing.T) {
+	var reqs []string
+	rdb := skytable.NewClient(&skytable.Options{
+		Transport: transportFunc(func(ctx context.Context, req []byte) ([]byte, error) {
+			reqs = append(reqs, string(req))
+			return []byte("*1\n+4\nHEY!\n"), nil
+		}),
+	})
+	defer rdb.Close()
+
+	val, err := rdb.Heya(ctx, "").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != "HEY!" {
+		t.Fatalf("got %q, wanted HEY!", val)
+	}
+	if want := "*1\n~1\n4\nHEYA\n"; len(reqs) != 1 || reqs[0] != want {
+		t.Fatalf("got requests %q, wanted %q", reqs, want)
+	}
+	if n := rdb.PoolStats().TotalConns; n != 0 {
+		t.Fatalf("got %d pooled conns, wanted 0", n)
+	}
+}
+
+// proxyTransport forwards queries to a server over one connection.
+type proxyTransport struct {
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+	log  []string
+}
+
+func (p *proxyTransport) RoundTrip(ctx context.Context, req []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.conn.Write(req); err != nil {
+		return nil, err
+	}
+	resp, err := skytable.ReadResponse(p.rd)
+	if err != nil {
+		return nil, err
+	}
+	p.log = append(p.log, string(resp))
+	return resp, nil
+}
+
+func TestTransportProxy(t *testing.T) {
+	kv := newFakeKeymap()
+	srv := newFakeServer(kv.handle)
+
+	conn, err := srv.options().Dialer(ctx, "tcp", "fake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	proxy := &proxyTransport{conn: conn, rd: bufio.NewReader(conn)}
+
+	rdb := skytable.NewClient(&skytable.Options{Transport: proxy})
+	defer rdb.Close()
+
+	if err := rdb.Set(ctx, "key", "value").Err(); err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := rdb.Pipelined(ctx, func(pipe skytable.Pipeliner) error {
+		pipe.Get(ctx, "key")
+		pipe.Get(ctx, "missing")
+		return nil
+	})
+	if err != skytable.Nil {
+		t.Fatalf("got %v, wanted %v", err, skytable.Nil)
+	}
+	if val := cmds[0].(*skytable.StringCmd).Val(); val != "value" {
+		t.Fatalf("got %q, wanted value", val)
+	}
+
+	want := []string{"*1\n!1\n0\n", "*2\n+5\nvalue\n!1\n1\n"}
+	if len(proxy.log) != len(want) {
+		t.Fatalf("got responses %q, wanted %q", proxy.log, want)
+	}
+	for i := range want {
+		if proxy.log[i] != want[i] {
+			t.Fatalf("got responses %q, wanted %q", proxy.log, want)
+		}
+	}
+}